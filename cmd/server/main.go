@@ -1,74 +1,185 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/vinsonio/security-report-collector/collector"
+	"github.com/vinsonio/security-report-collector/config"
+	"github.com/vinsonio/security-report-collector/database"
+	"github.com/vinsonio/security-report-collector/handler"
 	"github.com/vinsonio/security-report-collector/internal/bootstrap"
-	"github.com/vinsonio/security-report-collector/internal/config"
-	"github.com/vinsonio/security-report-collector/internal/handler"
-	"github.com/vinsonio/security-report-collector/internal/queue"
-	"github.com/vinsonio/security-report-collector/internal/router"
 	"github.com/vinsonio/security-report-collector/internal/scheduler"
-	"github.com/vinsonio/security-report-collector/internal/service"
+	"github.com/vinsonio/security-report-collector/queue"
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
 )
 
-func buildRouter() (http.Handler, error) {
-	db, cache, err := bootstrap.Init()
+// newCollector builds the collector configured by the environment.
+func newCollector() (*collector.Collector, error) {
+	db, err := bootstrap.Init()
 	if err != nil {
 		return nil, err
 	}
 
 	appConfig := config.NewApp()
-	reportService := service.NewReportService(db, cache, appConfig.CacheEnabled)
+	if path := appConfig.ReportTypesFile; path != "" {
+		if err := registerCustomReportTypes(registry.Default, path); err != nil {
+			return nil, err
+		}
+	}
 
-	return buildRouterWithService(reportService)
-}
+	opts := []collector.Option{collector.WithDB(db)}
 
-// buildRouterWithService constructs the HTTP router using the provided service.
-func buildRouterWithService(reportService *service.ReportService) (http.Handler, error) {
-	reportHandlers := map[string]handler.ReportHandler{
-		"csp": &handler.CSPReportHandler{},
+	// Queue reports and flush them to the database in batches
+	if appConfig.CacheEnabled {
+		q, err := queue.New(config.NewQueue(), "reports")
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize queue: %w", err)
+		}
+		interval := time.Duration(appConfig.FlushIntervalMinutes) * time.Minute
+		opts = append(opts,
+			collector.WithQueue(q),
+			collector.WithBatchFlush(appConfig.BatchSize, interval),
+		)
 	}
 
-	r := router.New(reportService, reportHandlers)
-	return r, nil
+	// Purge expired reports when a retention policy is configured
+	if retention := config.NewRetention(); retention.Enabled() {
+		if _, ok := db.(database.Purger); ok {
+			interval := time.Duration(retention.IntervalMinutes) * time.Minute
+			opts = append(opts, collector.WithRetention(retentionPolicy(retention), interval))
+		} else {
+			log.Printf("Retention policy ignored: the %s database does not support purging", config.NewDB().Connection)
+		}
+	}
+
+	return collector.New(opts...)
 }
 
-func main() {
-	// Application bootstrap
-	db, cache, err := bootstrap.Init()
+// registerCustomReportTypes registers the user-defined report types declared in
+// the file at path and applies its fingerprint configuration.
+func registerCustomReportTypes(reg *registry.Registry, path string) error {
+	reportTypes, err := config.LoadReportTypes(path)
 	if err != nil {
-		log.Fatalf("failed to initialize app: %v", err)
+		return err
 	}
 
-	appConfig := config.NewApp()
-	reportService := service.NewReportService(db, cache, appConfig.CacheEnabled)
+	for _, def := range reportTypes.Custom {
+		if _, exists := reg.Lookup(def.Name); exists {
+			return fmt.Errorf("custom report type %s conflicts with a registered report type", def.Name)
+		}
 
-	// Start background flusher (queue + scheduler) as part of app lifecycle, not router construction
-	if appConfig.CacheEnabled {
-		cacheCfg := config.NewCache()
-		q, err := queue.New(cacheCfg, "reports")
+		h, err := handler.NewCustomReportHandler(def)
 		if err != nil {
-			log.Fatalf("failed to initialize queue: %v", err)
+			return err
+		}
+		if err := reg.Register(h.ReportType()); err != nil {
+			return err
 		}
-		reportService.AttachQueue(q)
+		log.Printf("Registered custom report type %s", def.Name)
+	}
 
-		flusher := scheduler.NewBatchFlusher(q, db, appConfig.BatchSize)
-		stop := make(chan struct{})
-		interval := time.Duration(appConfig.FlushIntervalMinutes) * time.Minute
-		go scheduler.Scheduler(interval, stop, flusher.Flush)
-		log.Printf("Batch flusher scheduler started (interval: %v, batchSize: %d)", interval, appConfig.BatchSize)
+	for name, fields := range reportTypes.Fingerprints {
+		fp := make([]types.FingerprintField, len(fields))
+		for i, field := range fields {
+			fp[i] = types.FingerprintField{Pointer: field.Pointer, Normalize: field.Normalize}
+		}
+		fingerprint, err := types.NewFingerprint(fp)
+		if err != nil {
+			return fmt.Errorf("fingerprint of %s: %w", name, err)
+		}
+		if err := reg.SetFingerprint(name, fingerprint); err != nil {
+			return fmt.Errorf("fingerprint of %s: %w", name, err)
+		}
+		log.Printf("Configured fingerprint of report type %s", name)
 	}
 
-	r, err := buildRouterWithService(reportService)
+	return nil
+}
+
+// retentionPolicy converts the retention configuration into a database retention policy.
+func retentionPolicy(cfg *config.Retention) database.RetentionPolicy {
+	return database.RetentionPolicy{
+		MaxAge:       cfg.MaxAge,
+		MaxAgeByType: cfg.MaxAgeByType,
+		MaxReports:   cfg.MaxReports,
+		BatchSize:    cfg.BatchSize,
+	}
+}
+
+// runPurge deletes the reports expired by the retention configuration once and
+// writes the number of deleted rows to stdout as JSON.
+func runPurge() error {
+	retention := config.NewRetention()
+	if !retention.Enabled() {
+		return fmt.Errorf("no retention policy configured; set RETENTION_DAYS, RETENTION_DAYS_BY_TYPE or RETENTION_MAX_REPORTS")
+	}
+
+	db, err := database.Get()
+	if err != nil {
+		return err
+	}
+	purger, ok := db.(database.Purger)
+	if !ok {
+		return fmt.Errorf("the %s database does not support retention policies", config.NewDB().Connection)
+	}
+	if err := db.Migrate(); err != nil {
+		return err
+	}
+
+	result, err := scheduler.NewPurger(purger, retentionPolicy(retention)).Purge()
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(os.Stdout).Encode(result)
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "purge" {
+		if err := runPurge(); err != nil {
+			log.Fatalf("failed to purge reports: %v", err)
+		}
+		return
+	}
+
+	c, err := newCollector()
 	if err != nil {
-		log.Fatalf("failed to build router: %v", err)
+		log.Fatalf("failed to initialize app: %v", err)
 	}
+	if err := c.Start(); err != nil {
+		log.Fatalf("failed to start app: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{Addr: ":8080", Handler: c}
+	go func() {
+		log.Println("Starting server on :8080")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down server")
 
-	log.Println("Starting server on :8080")
-	if err := http.ListenAndServe(":8080", r); err != nil {
-		log.Fatal(err)
+	// Stop accepting reports before the queued reports are flushed
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("failed to shut down server: %v", err)
+	}
+	if err := c.Shutdown(shutdownCtx); err != nil {
+		log.Printf("failed to shut down collector: %v", err)
 	}
 }
@@ -3,26 +3,29 @@ package main
 import (
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"github.com/vinsonio/security-report-collector/internal/cache"
-	"github.com/vinsonio/security-report-collector/internal/database"
+	"github.com/vinsonio/security-report-collector/database"
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
 )
 
-func TestBuildRouter_Succeeds(t *testing.T) {
+func TestNewCollector_Succeeds(t *testing.T) {
 	// Reset singletons to ensure a fresh init
 	database.ResetSingletonForTest()
-	cache.ResetSingletonForTest()
 
-	// Force sqlite and file cache to avoid CI env leakage
+	// Force sqlite to avoid CI env leakage
 	t.Setenv("DB_CONNECTION", "sqlite")
-	t.Setenv("CACHE_DRIVER", "file")
 	// Use temp sqlite file
 	t.Setenv("DB_DATABASE", t.TempDir()+"/srv.db")
 
-	r, err := buildRouter()
+	r, err := newCollector()
 	require.NoError(t, err)
 	require.NotNil(t, r)
 
@@ -32,13 +35,117 @@ func TestBuildRouter_Succeeds(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
-func TestBuildRouter_InitFailure(t *testing.T) {
+func TestNewCollector_InitFailure(t *testing.T) {
 	// Reset singletons so that invalid driver is re-evaluated
 	database.ResetSingletonForTest()
-	cache.ResetSingletonForTest()
 
 	t.Setenv("DB_CONNECTION", "invalid")
 
-	_, err := buildRouter()
+	_, err := newCollector()
 	require.Error(t, err)
 }
+
+func TestNewCollector_CustomReportTypes(t *testing.T) {
+	database.ResetSingletonForTest()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report-types.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"custom":[{"name":"app-telemetry","schema":{"type":"object","required":["app"]},"fingerprint":["/app"]}]}`), 0644))
+
+	t.Setenv("DB_CONNECTION", "sqlite")
+	t.Setenv("DB_DATABASE", dir+"/srv.db")
+	t.Setenv("REPORT_TYPES_FILE", path)
+	t.Cleanup(func() { registry.Default.Unregister("app-telemetry") })
+
+	r, err := newCollector()
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/reports/app-telemetry", strings.NewReader(`{"app":"billing"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/reports/app-telemetry", strings.NewReader(`{"other":true}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestNewCollector_CustomReportTypeConflict(t *testing.T) {
+	database.ResetSingletonForTest()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report-types.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"custom":[{"name":"csp","schema":{},"fingerprint":["/a"]}]}`), 0644))
+
+	t.Setenv("DB_CONNECTION", "sqlite")
+	t.Setenv("DB_DATABASE", dir+"/srv.db")
+	t.Setenv("REPORT_TYPES_FILE", path)
+
+	_, err := newCollector()
+	require.Error(t, err)
+}
+
+func TestRegisterCustomReportTypes_Fingerprints(t *testing.T) {
+	csp, ok := registry.Lookup("csp")
+	require.True(t, ok)
+	reg := registry.New()
+	require.NoError(t, reg.Register(csp))
+
+	path := filepath.Join(t.TempDir(), "report-types.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"fingerprints":{"csp":[
+		{"pointer":"/body/documentURL","normalize":["strip_query"]},
+		{"pointer":"/body/effectiveDirective"},
+		{"pointer":"/body/blockedURL","normalize":["origin"]}
+	]}}`), 0644))
+	require.NoError(t, registerCustomReportTypes(reg, path))
+
+	first := types.CSPReport{Body: types.CSPReportBody{DocumentURL: "https://example.com/?session=1", EffectiveDirective: "script-src", BlockedURL: "https://cdn.example/a.js", LineNumber: 10}}
+	second := types.CSPReport{Body: types.CSPReportBody{DocumentURL: "https://example.com/?session=2", EffectiveDirective: "script-src", BlockedURL: "https://cdn.example/b.js", LineNumber: 12}}
+	firstData, err := reg.HashData("csp", first)
+	require.NoError(t, err)
+	secondData, err := reg.HashData("csp", second)
+	require.NoError(t, err)
+	assert.Equal(t, firstData, secondData)
+
+	for name, content := range map[string]string{
+		"unknown type":       `{"fingerprints":{"missing":[{"pointer":"/a"}]}}`,
+		"unknown normalizer": `{"fingerprints":{"csp":[{"pointer":"/a","normalize":["rot13"]}]}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+			assert.Error(t, registerCustomReportTypes(reg, path))
+		})
+	}
+}
+
+func TestRunPurge(t *testing.T) {
+	database.ResetSingletonForTest()
+	t.Cleanup(database.ResetSingletonForTest)
+
+	t.Setenv("DB_CONNECTION", "sqlite")
+	t.Setenv("DB_DATABASE", t.TempDir()+"/srv.db")
+	t.Setenv("RETENTION_DAYS", "30")
+
+	db, err := database.Get()
+	require.NoError(t, err)
+	require.NoError(t, db.Migrate())
+	report := types.NELReport{URL: "https://example.com"}
+	require.NoError(t, db.Record(database.NewOccurrence("nel", report, "UA", "old", time.Now().Add(-60*24*time.Hour))))
+	require.NoError(t, db.Record(database.NewOccurrence("nel", report, "UA", "new", time.Now())))
+
+	require.NoError(t, runPurge())
+
+	page, err := db.(database.Reader).Query(database.ReportFilter{})
+	require.NoError(t, err)
+	require.Len(t, page.Reports, 1)
+	assert.Equal(t, "new", page.Reports[0].Hash)
+}
+
+func TestRunPurge_NoPolicy(t *testing.T) {
+	t.Setenv("RETENTION_DAYS", "0")
+	t.Setenv("RETENTION_DAYS_BY_TYPE", "")
+	t.Setenv("RETENTION_MAX_REPORTS", "0")
+
+	assert.Error(t, runPurge())
+}
@@ -0,0 +1,280 @@
+// Package collector embeds the security report collector in another Go program.
+// A Collector serves the report endpoints, the read API and the dashboard as an
+// http.Handler, and runs the batch flusher and the retention purge job between
+// Start and Shutdown:
+//
+//	c, err := collector.New(collector.WithDB(db))
+//	if err != nil {
+//		return err
+//	}
+//	if err := c.Start(); err != nil {
+//		return err
+//	}
+//	defer c.Shutdown(context.Background())
+//	mux.Handle("/", c)
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/vinsonio/security-report-collector/database"
+	"github.com/vinsonio/security-report-collector/internal/router"
+	"github.com/vinsonio/security-report-collector/internal/scheduler"
+	"github.com/vinsonio/security-report-collector/queue"
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/service"
+)
+
+// Defaults of the background jobs, matching the defaults of the server.
+const (
+	DefaultBatchSize         = 100
+	DefaultFlushInterval     = 15 * time.Minute
+	DefaultRetentionInterval = time.Hour
+)
+
+// Option configures a Collector.
+type Option func(*options)
+
+type options struct {
+	db                database.DB
+	queue             queue.Queue
+	reportTypes       []registry.ReportType
+	batchSize         int
+	flushInterval     time.Duration
+	retention         *database.RetentionPolicy
+	retentionInterval time.Duration
+}
+
+// WithDB sets the database reports are stored in. It is required; see
+// database.New for the backends configured by DB_CONNECTION. The read API and
+// the dashboard are only served for databases implementing database.Reader,
+// and WithRetention requires a database.Purger.
+func WithDB(db database.DB) Option {
+	return func(o *options) {
+		o.db = db
+	}
+}
+
+// WithQueue queues received reports and writes them to the database in batches
+// (see WithBatchFlush). Shutdown flushes the queued reports and closes the queue.
+// Queues storing encoded reports decode them with the registry of the collector
+// if they have a SetRegistry method, like queue.RedisQueue and queue.FileQueue.
+func WithQueue(q queue.Queue) Option {
+	return func(o *options) {
+		o.queue = q
+	}
+}
+
+// WithReportType registers a report type, e.g. one with a handler of its own
+// (see handler.ReportHandler), with the collector. Each collector has a registry
+// of its own, holding the types of registry.Default when New is called and the
+// types of its options, which are served under /reports/{name}.
+func WithReportType(rt registry.ReportType) Option {
+	return func(o *options) {
+		o.reportTypes = append(o.reportTypes, rt)
+	}
+}
+
+// WithBatchFlush sets the number of queued reports written to the database at
+// a time and the interval between writes.
+func WithBatchFlush(batchSize int, interval time.Duration) Option {
+	return func(o *options) {
+		o.batchSize = batchSize
+		o.flushInterval = interval
+	}
+}
+
+// WithRetention purges the reports expired by the policy at the given interval.
+func WithRetention(policy database.RetentionPolicy, interval time.Duration) Option {
+	return func(o *options) {
+		o.retention = &policy
+		o.retentionInterval = interval
+	}
+}
+
+// Collector is an embeddable security report collector.
+type Collector struct {
+	opts    options
+	service *service.ReportService
+	handler http.Handler
+	flusher *scheduler.BatchFlusher
+	purger  database.Purger
+
+	mutex    sync.Mutex
+	started  bool
+	shutdown bool
+	stop     chan struct{}
+	jobs     sync.WaitGroup
+}
+
+// New creates a Collector and migrates its database.
+func New(opts ...Option) (*Collector, error) {
+	o := options{
+		batchSize:         DefaultBatchSize,
+		flushInterval:     DefaultFlushInterval,
+		retentionInterval: DefaultRetentionInterval,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.db == nil {
+		return nil, errors.New("collector: a database is required")
+	}
+	if o.queue != nil && (o.batchSize < 1 || o.flushInterval <= 0) {
+		return nil, fmt.Errorf("collector: invalid batch flush: %d reports every %v", o.batchSize, o.flushInterval)
+	}
+	if o.retention != nil && o.retentionInterval <= 0 {
+		return nil, fmt.Errorf("collector: invalid retention interval: %v", o.retentionInterval)
+	}
+	purger, _ := o.db.(database.Purger)
+	if o.retention != nil && purger == nil {
+		return nil, errors.New("collector: the database does not support retention policies")
+	}
+
+	reportTypes := registry.Default.Clone()
+	for _, rt := range o.reportTypes {
+		if err := reportTypes.Register(rt); err != nil {
+			return nil, fmt.Errorf("collector: %w", err)
+		}
+	}
+
+	if err := o.db.Migrate(); err != nil {
+		return nil, fmt.Errorf("collector: migrate database: %w", err)
+	}
+
+	reportService := service.NewReportService(o.db)
+	reportService.SetRegistry(reportTypes)
+	if d, ok := o.db.(database.Deduplicator); ok {
+		reportService.SetDedupWindow(d.DedupWindow())
+	}
+
+	c := &Collector{
+		opts:    o,
+		service: reportService,
+		purger:  purger,
+	}
+	if o.queue != nil {
+		if q, ok := o.queue.(interface{ SetRegistry(*registry.Registry) }); ok {
+			q.SetRegistry(reportTypes)
+		}
+		reportService.AttachQueue(o.queue)
+		c.flusher = scheduler.NewBatchFlusher(o.queue, o.db, o.batchSize)
+	}
+	c.handler = router.New(reportService, reportTypes.Handlers())
+
+	return c, nil
+}
+
+// ServeHTTP serves the routes of the collector: the report endpoints under
+// /reports, the read API under /api, the dashboard under /ui and /healthz.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.handler.ServeHTTP(w, r)
+}
+
+// Service returns the report service, e.g. to save reports received by other means.
+func (c *Collector) Service() *service.ReportService {
+	return c.service
+}
+
+// Start starts the background jobs: the batch flusher when a queue is set and
+// the retention purge job when a retention policy is set.
+func (c *Collector) Start() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.shutdown {
+		return errors.New("collector: already shut down")
+	}
+	if c.started {
+		return errors.New("collector: already started")
+	}
+	c.started = true
+	c.stop = make(chan struct{})
+
+	if c.flusher != nil {
+		c.run(c.opts.flushInterval, c.flusher.Flush)
+		log.Printf("Batch flusher scheduler started (interval: %v, batchSize: %d)", c.opts.flushInterval, c.opts.batchSize)
+	}
+
+	if c.opts.retention != nil {
+		purger := scheduler.NewPurger(c.purger, *c.opts.retention)
+		c.run(c.opts.retentionInterval, purger.Run)
+		log.Printf("Retention purge scheduler started (interval: %v)", c.opts.retentionInterval)
+	}
+
+	return nil
+}
+
+// run runs a job at a fixed interval until the collector is shut down.
+func (c *Collector) run(interval time.Duration, job func() error) {
+	c.jobs.Add(1)
+	go func() {
+		defer c.jobs.Done()
+		scheduler.Scheduler(interval, c.stop, job)
+	}()
+}
+
+// Shutdown stops the background jobs, waiting for running jobs to finish, then
+// writes the queued reports to the database and closes the queue. It returns
+// the context's error if the context is done first. Requests being served are
+// not waited for; shut down the HTTP server first.
+func (c *Collector) Shutdown(ctx context.Context) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.shutdown {
+		return nil
+	}
+	c.shutdown = true
+
+	if c.started {
+		close(c.stop)
+
+		done := make(chan struct{})
+		go func() {
+			c.jobs.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if c.flusher == nil {
+		return nil
+	}
+	if err := c.drain(ctx); err != nil {
+		return err
+	}
+	return c.opts.queue.Close()
+}
+
+// drain flushes batches until the queue is empty, or stops shrinking.
+func (c *Collector) drain(ctx context.Context) error {
+	previous := -1
+	for {
+		size, err := c.opts.queue.Size()
+		if err != nil {
+			return err
+		}
+		if size == 0 || (previous >= 0 && size >= previous) {
+			return nil
+		}
+		previous = size
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := c.flusher.Flush(); err != nil {
+			return err
+		}
+	}
+}
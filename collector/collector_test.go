@@ -0,0 +1,200 @@
+package collector_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vinsonio/security-report-collector/collector"
+	"github.com/vinsonio/security-report-collector/config"
+	"github.com/vinsonio/security-report-collector/database"
+	"github.com/vinsonio/security-report-collector/queue"
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// pingReport is a report type defined outside of the collector.
+type pingReport struct {
+	Service string `json:"service"`
+}
+
+func (r pingReport) Type() string {
+	return "ping"
+}
+
+func (r pingReport) JSON() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+func (r pingReport) HashData() (interface{}, error) {
+	return r, nil
+}
+
+type pingHandler struct{}
+
+func (pingHandler) Handle(r *http.Request) (types.Report, error) {
+	var report pingReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+var pingType = registry.ReportType{
+	Name:    "ping",
+	Handler: pingHandler{},
+	Decode:  registry.JSONDecoder[pingReport](),
+}
+
+func newDB(t *testing.T, dedupWindow time.Duration) database.Store {
+	db, err := database.NewSQLiteDB(config.SQLite{Database: filepath.Join(t.TempDir(), "collector.db")}, dedupWindow)
+	require.NoError(t, err)
+	return db
+}
+
+// postPing sends a ping report to the collector.
+func postPing(t *testing.T, c http.Handler, service string) {
+	req := httptest.NewRequest(http.MethodPost, "/reports/ping", strings.NewReader(`{"service":"`+service+`"}`))
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func countReports(t *testing.T, db database.Store) int {
+	page, err := db.Query(database.ReportFilter{Type: "ping"})
+	require.NoError(t, err)
+	return len(page.Reports)
+}
+
+func TestNew_RequiresDB(t *testing.T) {
+	_, err := collector.New()
+	assert.Error(t, err)
+}
+
+func TestCollector_ServesReportTypes(t *testing.T) {
+	db := newDB(t, 0)
+	c, err := collector.New(collector.WithDB(db), collector.WithReportType(pingType))
+	require.NoError(t, err)
+
+	postPing(t, c, "billing")
+	assert.Equal(t, 1, countReports(t, db))
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The service saves reports received by other means
+	require.NoError(t, c.Service().SaveReport("ping", pingReport{Service: "search"}, "worker"))
+	assert.Equal(t, 2, countReports(t, db))
+
+	// A report type is registered once per collector
+	_, err = collector.New(collector.WithDB(db), collector.WithReportType(pingType), collector.WithReportType(pingType))
+	assert.Error(t, err)
+}
+
+func TestCollector_OwnRegistry(t *testing.T) {
+	db := newDB(t, 0)
+	first, err := collector.New(collector.WithDB(db), collector.WithReportType(pingType))
+	require.NoError(t, err)
+	second, err := collector.New(collector.WithDB(db), collector.WithReportType(pingType))
+	require.NoError(t, err)
+	other, err := collector.New(collector.WithDB(db))
+	require.NoError(t, err)
+
+	postPing(t, first, "billing")
+	postPing(t, second, "search")
+	assert.Equal(t, 2, countReports(t, db))
+
+	// Report types of a collector are not seen by other collectors
+	_, ok := registry.Lookup("ping")
+	assert.False(t, ok)
+	req := httptest.NewRequest(http.MethodPost, "/reports/ping", strings.NewReader(`{"service":"billing"}`))
+	w := httptest.NewRecorder()
+	other.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// The built-in report types are served by every collector
+	req = httptest.NewRequest(http.MethodPost, "/reports/nel", strings.NewReader(`{"url":"https://example.com/"}`))
+	w = httptest.NewRecorder()
+	other.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestCollector_DedupWindowOfDB(t *testing.T) {
+	db := newDB(t, time.Hour)
+	q := queue.NewInMemoryQueue()
+	c, err := collector.New(collector.WithDB(db), collector.WithQueue(q), collector.WithReportType(pingType))
+	require.NoError(t, err)
+
+	postPing(t, c, "billing")
+
+	envelopes, err := q.DequeueN(1)
+	require.NoError(t, err)
+	require.Len(t, envelopes, 1)
+	assert.Equal(t, database.DedupKey(envelopes[0].Hash, envelopes[0].Timestamp, time.Hour), envelopes[0].Key)
+}
+
+// sinkDB is a database reports are only written to.
+type sinkDB struct {
+	database.DB
+}
+
+func TestCollector_WriteOnlyDB(t *testing.T) {
+	t.Setenv("API_PUBLIC", "true")
+	db := newDB(t, 0)
+	c, err := collector.New(collector.WithDB(sinkDB{db}), collector.WithReportType(pingType))
+	require.NoError(t, err)
+
+	postPing(t, c, "billing")
+	assert.Equal(t, 1, countReports(t, db))
+	assert.False(t, c.Service().Readable())
+
+	// The read API and the dashboard are not served
+	for _, path := range []string{"/api/reports", "/ui/"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		c.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+	}
+
+	// Retention policies need a database.Purger
+	_, err = collector.New(collector.WithDB(sinkDB{db}), collector.WithRetention(database.RetentionPolicy{MaxReports: 1}, time.Hour))
+	assert.Error(t, err)
+}
+
+func TestCollector_ShutdownFlushesQueue(t *testing.T) {
+	db := newDB(t, 0)
+	q := queue.NewInMemoryQueue()
+	c, err := collector.New(
+		collector.WithDB(db),
+		collector.WithQueue(q),
+		collector.WithBatchFlush(1, time.Hour),
+		collector.WithReportType(pingType),
+	)
+	require.NoError(t, err)
+	require.NoError(t, c.Start())
+	assert.Error(t, c.Start())
+
+	postPing(t, c, "billing")
+	postPing(t, c, "search")
+	postPing(t, c, "billing")
+	assert.Equal(t, 0, countReports(t, db))
+
+	require.NoError(t, c.Shutdown(context.Background()))
+	assert.Equal(t, 2, countReports(t, db))
+
+	size, err := q.Size()
+	require.NoError(t, err)
+	assert.Equal(t, 0, size)
+
+	assert.NoError(t, c.Shutdown(context.Background()))
+	assert.Error(t, c.Start())
+}
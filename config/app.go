@@ -13,6 +13,7 @@ type App struct {
 	CacheEnabled         bool
 	FlushIntervalMinutes int
 	BatchSize            int
+	ReportTypesFile      string
 }
 
 // NewApp creates a new App configuration.
@@ -24,6 +25,7 @@ func NewApp() *App {
 		CacheEnabled:         getEnvAsBool("CACHE_ENABLED", false),
 		FlushIntervalMinutes: getEnvAsInt("BATCH_FLUSH_INTERVAL_MINUTES", 15),
 		BatchSize:            getEnvAsInt("BATCH_FLUSH_BATCH_SIZE", 100),
+		ReportTypesFile:      getEnv("REPORT_TYPES_FILE", ""),
 	}
 }
 
@@ -6,17 +6,11 @@ import (
 	"strings"
 )
 
-// Cache holds the cache configuration.
+// Cache holds the cache configuration. Reports are not cached; a redis Driver
+// makes Redis the default queue driver (see NewQueue).
 type Cache struct {
-	Driver    string
-	File      FileCache
-	Redis     Redis
-	Memcached Memcached
-}
-
-// FileCache holds the file cache configuration.
-type FileCache struct {
-	Dir string
+	Driver string
+	Redis  Redis
 }
 
 // Redis holds the Redis configuration.
@@ -26,26 +20,20 @@ type Redis struct {
 	DB       int
 }
 
-// Memcached holds the Memcached configuration.
-type Memcached struct {
-	Servers []string
-}
-
 // NewCache creates a new Cache configuration.
 func NewCache() *Cache {
 	return &Cache{
 		Driver: getEnv("CACHE_DRIVER", "file"),
-		File: FileCache{
-			Dir: getEnv("FILE_CACHE_DIR", "cache"),
-		},
-		Redis: Redis{
-			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
-			Password: getEnv("REDIS_PASSWORD", ""),
-			DB:       getEnvAsInt("REDIS_DB", 0),
-		},
-		Memcached: Memcached{
-			Servers: getEnvAsSlice("MEMCACHED_SERVERS", []string{"localhost:11211"}, ","),
-		},
+		Redis:  newRedis(),
+	}
+}
+
+// newRedis creates a new Redis configuration, shared by the cache and the queue.
+func newRedis() Redis {
+	return Redis{
+		Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
+		Password: getEnv("REDIS_PASSWORD", ""),
+		DB:       getEnvAsInt("REDIS_DB", 0),
 	}
 }
 
@@ -0,0 +1,21 @@
+package config
+
+// Config holds all configurations for the application.
+type Config struct {
+	App       *App
+	Cache     *Cache
+	Queue     *Queue
+	DB        *DB
+	Retention *Retention
+}
+
+// New creates a new Config instance.
+func New() *Config {
+	return &Config{
+		App:       NewApp(),
+		Cache:     NewCache(),
+		Queue:     NewQueue(),
+		DB:        NewDB(),
+		Retention: NewRetention(),
+	}
+}
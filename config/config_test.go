@@ -0,0 +1,78 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConfig_Defaults(t *testing.T) {
+	// Explicitly set defaults that New() would use when env is unset
+	t.Setenv("APP_NAME", "report-collector")
+	t.Setenv("DB_CONNECTION", "sqlite")
+
+	cfg := New()
+	assert.NotNil(t, cfg.App)
+	assert.NotNil(t, cfg.Cache)
+	assert.NotNil(t, cfg.DB)
+	assert.Equal(t, "sqlite", cfg.DB.Connection)
+}
+
+func TestNewDB_FromEnv(t *testing.T) {
+	t.Setenv("DB_CONNECTION", "mysql")
+	t.Setenv("DB_DATABASE", "urc")
+	t.Setenv("DB_HOST", "db.example")
+	t.Setenv("DB_PORT", "3307")
+	t.Setenv("DB_USER", "user")
+	t.Setenv("DB_PASSWORD", "pass")
+
+	db := NewDB()
+	assert.Equal(t, "mysql", db.Connection)
+	assert.Equal(t, "urc", db.MySQL.Database)
+	assert.Equal(t, "db.example", db.MySQL.Host)
+	assert.Equal(t, 3307, db.MySQL.Port)
+	assert.Equal(t, "user", db.MySQL.User)
+	assert.Equal(t, "pass", db.MySQL.Password)
+}
+
+func TestNewRetention_FromEnv(t *testing.T) {
+	t.Setenv("RETENTION_DAYS", "90")
+	t.Setenv("RETENTION_DAYS_BY_TYPE", "csp=30, nel=0,bogus,deprecation=x")
+	t.Setenv("RETENTION_MAX_REPORTS", "100000")
+	t.Setenv("RETENTION_BATCH_SIZE", "200")
+	t.Setenv("RETENTION_INTERVAL_MINUTES", "30")
+
+	r := NewRetention()
+	assert.Equal(t, 90*24*time.Hour, r.MaxAge)
+	assert.Equal(t, map[string]time.Duration{"csp": 30 * 24 * time.Hour, "nel": 0}, r.MaxAgeByType)
+	assert.Equal(t, 100000, r.MaxReports)
+	assert.Equal(t, 200, r.BatchSize)
+	assert.Equal(t, 30, r.IntervalMinutes)
+	assert.True(t, r.Enabled())
+}
+
+func TestNewRetention_DisabledByDefault(t *testing.T) {
+	t.Setenv("RETENTION_DAYS", "")
+	t.Setenv("RETENTION_DAYS_BY_TYPE", "")
+	t.Setenv("RETENTION_MAX_REPORTS", "")
+
+	r := NewRetention()
+	assert.False(t, r.Enabled())
+	assert.Equal(t, 500, r.BatchSize)
+}
+
+func TestNewDB_Postgres(t *testing.T) {
+	t.Setenv("DB_CONNECTION", "postgres")
+	t.Setenv("DB_HOST", "pg.example")
+	t.Setenv("DB_PORT", "")
+	t.Setenv("DB_USER", "collector")
+	t.Setenv("DB_SSLMODE", "require")
+
+	db := NewDB()
+	assert.Equal(t, "postgres", db.Connection)
+	assert.Equal(t, "pg.example", db.Postgres.Host)
+	assert.Equal(t, 5432, db.Postgres.Port)
+	assert.Equal(t, "collector", db.Postgres.User)
+	assert.Equal(t, "require", db.Postgres.SSLMode)
+}
@@ -0,0 +1,66 @@
+package config
+
+import "time"
+
+// DB holds the database configuration.
+type DB struct {
+	Connection string
+	// DedupWindow is how long after the last duplicate a report hash is
+	// deduplicated for; a report received after the window is stored as a new
+	// report. Zero deduplicates forever.
+	DedupWindow time.Duration
+	SQLite      SQLite
+	MySQL       MySQL
+	Postgres    Postgres
+}
+
+// SQLite holds the SQLite database configuration.
+type SQLite struct {
+	Database string
+}
+
+// MySQL holds the MySQL database configuration.
+type MySQL struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Database string
+}
+
+// Postgres holds the PostgreSQL database configuration.
+type Postgres struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Database string
+	// SSLMode is the libpq sslmode, e.g. disable, require or verify-full.
+	SSLMode string
+}
+
+// NewDB creates a new DB configuration.
+func NewDB() *DB {
+	return &DB{
+		Connection:  getEnv("DB_CONNECTION", "sqlite"),
+		DedupWindow: time.Duration(getEnvAsInt("DEDUP_WINDOW_HOURS", 0)) * time.Hour,
+		SQLite: SQLite{
+			Database: getEnv("DB_DATABASE", "reports.db"),
+		},
+		MySQL: MySQL{
+			Host:     getEnv("DB_HOST", "localhost"),
+			Port:     getEnvAsInt("DB_PORT", 3306),
+			User:     getEnv("DB_USER", "root"),
+			Password: getEnv("DB_PASSWORD", ""),
+			Database: getEnv("DB_DATABASE", "reports"),
+		},
+		Postgres: Postgres{
+			Host:     getEnv("DB_HOST", "localhost"),
+			Port:     getEnvAsInt("DB_PORT", 5432),
+			User:     getEnv("DB_USER", "postgres"),
+			Password: getEnv("DB_PASSWORD", ""),
+			Database: getEnv("DB_DATABASE", "reports"),
+			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+		},
+	}
+}
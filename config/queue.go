@@ -0,0 +1,32 @@
+package config
+
+// Queue holds the configuration of the queue reports are batched in when
+// CACHE_ENABLED is set.
+type Queue struct {
+	// Driver is memory, redis or file. It defaults to redis with the redis
+	// cache driver, and to file otherwise.
+	Driver string
+	File   FileQueue
+	Redis  Redis
+}
+
+// FileQueue holds the file queue configuration.
+type FileQueue struct {
+	Dir string
+}
+
+// NewQueue creates a new Queue configuration.
+func NewQueue() *Queue {
+	driver := "file"
+	if getEnv("CACHE_DRIVER", "file") == "redis" {
+		driver = "redis"
+	}
+
+	return &Queue{
+		Driver: getEnv("QUEUE_DRIVER", driver),
+		File: FileQueue{
+			Dir: getEnv("QUEUE_FILE_DIR", "data/queue"),
+		},
+		Redis: newRedis(),
+	}
+}
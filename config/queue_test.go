@@ -0,0 +1,30 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewQueue_DriverFollowsCache(t *testing.T) {
+	t.Setenv("CACHE_DRIVER", "redis")
+	t.Setenv("REDIS_ADDR", "127.0.0.1:6380")
+	cfg := NewQueue()
+	assert.Equal(t, "redis", cfg.Driver)
+	assert.Equal(t, "127.0.0.1:6380", cfg.Redis.Addr)
+
+	t.Setenv("CACHE_DRIVER", "file")
+	cfg = NewQueue()
+	assert.Equal(t, "file", cfg.Driver)
+	assert.Equal(t, "data/queue", cfg.File.Dir)
+}
+
+func TestNewQueue_FromEnv(t *testing.T) {
+	t.Setenv("CACHE_DRIVER", "redis")
+	t.Setenv("QUEUE_DRIVER", "file")
+	t.Setenv("QUEUE_FILE_DIR", "/var/lib/collector/queue")
+
+	cfg := NewQueue()
+	assert.Equal(t, "file", cfg.Driver)
+	assert.Equal(t, "/var/lib/collector/queue", cfg.File.Dir)
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// reportTypeName matches valid report type names, which are also used as URL path segments.
+var reportTypeName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ReportTypes holds the report type declarations loaded from REPORT_TYPES_FILE.
+type ReportTypes struct {
+	Custom []CustomReportType `json:"custom"`
+	// Fingerprints replaces the fields that make up the hash of report types,
+	// built-in or custom, keyed by report type.
+	Fingerprints map[string][]FingerprintField `json:"fingerprints"`
+}
+
+// FingerprintField selects a report value for the report hash.
+type FingerprintField struct {
+	// Pointer is the JSON pointer (RFC 6901) of the value within the stored report.
+	Pointer string `json:"pointer"`
+	// Normalize lists the normalizers applied to the value, e.g. "strip_query".
+	Normalize []string `json:"normalize,omitempty"`
+}
+
+// CustomReportType declares a user-defined report type.
+type CustomReportType struct {
+	// Name is the report type, served at /reports/{name}.
+	Name string `json:"name"`
+	// Schema is the JSON Schema reports are validated against.
+	Schema json.RawMessage `json:"schema"`
+	// Fingerprint lists the JSON pointers (RFC 6901) whose values make up the report hash.
+	Fingerprint []string `json:"fingerprint"`
+}
+
+// LoadReportTypes reads report type declarations from a JSON file.
+func LoadReportTypes(path string) (*ReportTypes, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rt ReportTypes
+	if err := json.Unmarshal(data, &rt); err != nil {
+		return nil, fmt.Errorf("invalid report types file %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool)
+	for _, ct := range rt.Custom {
+		if !reportTypeName.MatchString(ct.Name) {
+			return nil, fmt.Errorf("invalid custom report type name: %q", ct.Name)
+		}
+		if seen[ct.Name] {
+			return nil, fmt.Errorf("duplicate custom report type: %s", ct.Name)
+		}
+		seen[ct.Name] = true
+
+		if len(ct.Schema) == 0 {
+			return nil, fmt.Errorf("custom report type %s: missing schema", ct.Name)
+		}
+		if len(ct.Fingerprint) == 0 {
+			return nil, fmt.Errorf("custom report type %s: missing fingerprint", ct.Name)
+		}
+		for _, ptr := range ct.Fingerprint {
+			if ptr != "" && ptr[0] != '/' {
+				return nil, fmt.Errorf("custom report type %s: invalid JSON pointer %q", ct.Name, ptr)
+			}
+		}
+	}
+
+	for name, fields := range rt.Fingerprints {
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("fingerprint of %s: missing fields", name)
+		}
+		for _, field := range fields {
+			if field.Pointer != "" && field.Pointer[0] != '/' {
+				return nil, fmt.Errorf("fingerprint of %s: invalid JSON pointer %q", name, field.Pointer)
+			}
+		}
+	}
+
+	return &rt, nil
+}
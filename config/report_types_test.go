@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeReportTypes(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "report-types.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadReportTypes(t *testing.T) {
+	path := writeReportTypes(t, `{"custom":[{"name":"login-anomaly","schema":{"type":"object"},"fingerprint":["/app","/event/kind"]}]}`)
+
+	rt, err := LoadReportTypes(path)
+	require.NoError(t, err)
+	require.Len(t, rt.Custom, 1)
+	assert.Equal(t, "login-anomaly", rt.Custom[0].Name)
+	assert.JSONEq(t, `{"type":"object"}`, string(rt.Custom[0].Schema))
+	assert.Equal(t, []string{"/app", "/event/kind"}, rt.Custom[0].Fingerprint)
+}
+
+func TestLoadReportTypes_Fingerprints(t *testing.T) {
+	path := writeReportTypes(t, `{"fingerprints":{"csp":[{"pointer":"/body/documentURL","normalize":["strip_query"]},{"pointer":"/body/effectiveDirective"}]}}`)
+
+	rt, err := LoadReportTypes(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]FingerprintField{
+		"csp": {
+			{Pointer: "/body/documentURL", Normalize: []string{"strip_query"}},
+			{Pointer: "/body/effectiveDirective"},
+		},
+	}, rt.Fingerprints)
+}
+
+func TestLoadReportTypes_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"malformed json":      `{"custom":`,
+		"invalid name":        `{"custom":[{"name":"Bad Name","schema":{},"fingerprint":["/a"]}]}`,
+		"duplicate name":      `{"custom":[{"name":"a","schema":{},"fingerprint":["/a"]},{"name":"a","schema":{},"fingerprint":["/a"]}]}`,
+		"missing schema":      `{"custom":[{"name":"a","fingerprint":["/a"]}]}`,
+		"missing fingerprint": `{"custom":[{"name":"a","schema":{}}]}`,
+		"invalid pointer":     `{"custom":[{"name":"a","schema":{},"fingerprint":["a"]}]}`,
+		"empty fingerprint":   `{"fingerprints":{"csp":[]}}`,
+		"invalid field":       `{"fingerprints":{"csp":[{"pointer":"body"}]}}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadReportTypes(writeReportTypes(t, content))
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := LoadReportTypes(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Retention holds the data retention configuration.
+type Retention struct {
+	// MaxAge is how long a report is kept after it was last seen. Zero keeps reports forever.
+	MaxAge time.Duration
+	// MaxAgeByType overrides MaxAge for report types; zero keeps reports of the type forever.
+	MaxAgeByType map[string]time.Duration
+	// MaxReports is the maximum number of stored reports. Zero disables the limit.
+	MaxReports int
+	// BatchSize is the number of rows deleted at a time.
+	BatchSize int
+	// IntervalMinutes is how often the purge job runs.
+	IntervalMinutes int
+}
+
+// NewRetention creates a new Retention configuration.
+func NewRetention() *Retention {
+	return &Retention{
+		MaxAge:          time.Duration(getEnvAsInt("RETENTION_DAYS", 0)) * 24 * time.Hour,
+		MaxAgeByType:    getEnvAsDays("RETENTION_DAYS_BY_TYPE"),
+		MaxReports:      getEnvAsInt("RETENTION_MAX_REPORTS", 0),
+		BatchSize:       getEnvAsInt("RETENTION_BATCH_SIZE", 500),
+		IntervalMinutes: getEnvAsInt("RETENTION_INTERVAL_MINUTES", 60),
+	}
+}
+
+// Enabled reports whether any retention limit is configured.
+func (r *Retention) Enabled() bool {
+	return r.MaxAge > 0 || len(r.MaxAgeByType) > 0 || r.MaxReports > 0
+}
+
+// getEnvAsDays returns the value of an environment variable of comma-separated
+// name=days pairs, e.g. "csp=30,nel=7", as durations by name. Malformed pairs are ignored.
+func getEnvAsDays(key string) map[string]time.Duration {
+	days := make(map[string]time.Duration)
+	for _, pair := range getEnvAsSlice(key, nil, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			continue
+		}
+		days[name] = time.Duration(n) * 24 * time.Hour
+	}
+	return days
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// ErrDuplicateReport is returned by databases that reject a report with the same
+// hash as an existing one. The built-in databases count duplicates as occurrences instead.
+var ErrDuplicateReport = errors.New("duplicate report")
+
+// ErrReportNotFound is returned when a report does not exist.
+var ErrReportNotFound = errors.New("report not found")
+
+// DB is the interface for a report database: a sink reports are written to.
+// Databases also serving the read API and the dashboard implement Reader, and
+// those supporting retention policies implement Purger.
+type DB interface {
+	Save(reportType string, report types.Report, userAgent, hash string) error
+	Record(occurrence Occurrence) error
+	Migrate() error
+}
+
+// Reader is implemented by databases serving the read API under /api,
+// including the triage of issues.
+type Reader interface {
+	Query(filter ReportFilter) (ReportPage, error)
+	Export(filter ReportFilter, fn func(StoredReport) error) error
+	Get(id string) (StoredReport, error)
+	Stats(query StatsQuery) ([]StatsGroup, error)
+	Issues(filter IssueFilter) (IssuePage, error)
+	GetIssue(id string) (Issue, error)
+	UpdateIssue(id string, update IssueUpdate) (Issue, error)
+}
+
+// Purger is implemented by databases deleting the reports expired by a
+// retention policy.
+type Purger interface {
+	Purge(policy RetentionPolicy) (PurgeResult, error)
+}
+
+// Deduplicator is implemented by databases counting reports with the same hash
+// as one report within a window. Databases not implementing it deduplicate
+// forever.
+type Deduplicator interface {
+	// DedupWindow returns how long reports with the same hash are counted as
+	// one report (see DedupKey); zero deduplicates forever.
+	DedupWindow() time.Duration
+}
+
+// Store is the interface of the built-in databases, which implement all of
+// the optional interfaces.
+type Store interface {
+	DB
+	Reader
+	Purger
+	Deduplicator
+}
@@ -0,0 +1,574 @@
+package database_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vinsonio/security-report-collector/config"
+	"github.com/vinsonio/security-report-collector/database"
+	dbtesting "github.com/vinsonio/security-report-collector/internal/testing"
+	"github.com/vinsonio/security-report-collector/types"
+	"log"
+)
+
+func TestMain(m *testing.M) {
+	// Set the environment variable to use the in-memory SQLite database for tests
+	if err := os.Setenv("DB_CONNECTION", "sqlite"); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Setenv("DB_URL", "file:test.db?mode=memory&cache=shared"); err != nil {
+		log.Fatal(err)
+	}
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestSaveDuplicateReport(t *testing.T) {
+	db := dbtesting.GetDBForTest(t)
+
+	report := types.CSPReport{
+		ReportType: "csp-violation",
+		URL:        "https://example.com",
+		Body: types.CSPReportBody{
+			DocumentURL:        "https://example.com",
+			Disposition:        "report",
+			Referrer:           "",
+			EffectiveDirective: "font-src",
+			BlockedURL:         "https://fonts.gstatic.com/s/inter/v19/UcC73FwrK3iLTeHuS_nVMrMxCp50SjIa2ZL7W0Q5n-wU.woff2",
+			OriginalPolicy:     "default-src 'self'; script-src 'self'; img-src: 'self'; report-to csp",
+			StatusCode:         200,
+			Sample:             "",
+			SourceFile:         "https://example.com",
+			LineNumber:         0,
+			ColumnNumber:       1,
+		},
+	}
+
+	hash := "d1692b293b40495a372cf2473551125d5635393da55b6942647b013b0c2a2a59"
+
+	// Save the report for the first time
+	err := db.Save("csp", report, "test-agent", hash)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, db.Count(t), "Report count should be 1 after first save")
+
+	// Save the same report again
+	err = db.Save("csp", report, "other-agent", hash)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, db.Count(t), "Report count should still be 1 after saving a duplicate")
+
+	page, err := db.Query(database.ReportFilter{Hash: hash})
+	require.NoError(t, err)
+	require.Len(t, page.Reports, 1)
+	stored := page.Reports[0]
+	assert.Equal(t, 2, stored.Occurrences)
+	assert.Equal(t, "test-agent", stored.UserAgent)
+	assert.Equal(t, []string{"test-agent", "other-agent"}, stored.UserAgents)
+	assert.False(t, stored.FirstSeen.After(stored.LastSeen))
+}
+
+func TestRecordOccurrence(t *testing.T) {
+	db := dbtesting.GetDBForTest(t)
+
+	report := types.NELReport{URL: "https://example.com", Body: types.NELReportBody{Phase: "dns"}}
+	first := time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+	last := first.Add(time.Hour)
+
+	occurrence := database.NewOccurrence("nel", report, "ua-1", "hash", last)
+	occurrence.Merge(database.NewOccurrence("nel", report, "ua-2", "hash", first))
+	occurrence.Merge(database.NewOccurrence("nel", report, "ua-1", "hash", first.Add(time.Minute)))
+	assert.Equal(t, 3, occurrence.Count)
+	require.NoError(t, db.Record(occurrence))
+
+	// A later batch with more user agents than are sampled
+	later := database.Occurrence{ReportType: "nel", Report: report, Hash: "hash", Count: 12, FirstSeen: last, LastSeen: last.Add(time.Hour)}
+	for i := 0; i < 12; i++ {
+		later.UserAgents = append(later.UserAgents, fmt.Sprintf("ua-%d", i+3))
+	}
+	require.NoError(t, db.Record(later))
+
+	page, err := db.Query(database.ReportFilter{Hash: "hash"})
+	require.NoError(t, err)
+	require.Len(t, page.Reports, 1)
+
+	stored := page.Reports[0]
+	assert.Equal(t, 15, stored.Occurrences)
+	assert.True(t, first.Equal(stored.FirstSeen), "first seen %s", stored.FirstSeen)
+	assert.True(t, last.Add(time.Hour).Equal(stored.LastSeen), "last seen %s", stored.LastSeen)
+	assert.Len(t, stored.UserAgents, database.MaxUserAgentSamples)
+	assert.Equal(t, []string{"ua-1", "ua-2", "ua-3"}, stored.UserAgents[:3])
+}
+
+func TestQueryReports(t *testing.T) {
+	db := dbtesting.GetDBForTest(t)
+
+	reports := []struct {
+		report    types.CSPReport
+		userAgent string
+	}{
+		{types.CSPReport{URL: "https://example.com", Body: types.CSPReportBody{EffectiveDirective: "script-src", BlockedURL: "https://evil.example/a.js", StatusCode: 200}}, "Mozilla/5.0 Firefox/128.0"},
+		{types.CSPReport{URL: "https://example.com", Body: types.CSPReportBody{EffectiveDirective: "img-src", BlockedURL: "https://cdn.example/a.png"}}, "Mozilla/5.0 Chrome/126.0"},
+		{types.CSPReport{URL: "https://example.com", Body: types.CSPReportBody{EffectiveDirective: "script-src", BlockedURL: "inline"}}, "Mozilla/5.0 Chrome/126.0"},
+	}
+	for i, r := range reports {
+		assert.NoError(t, db.Save("csp", r.report, r.userAgent, fmt.Sprintf("hash-%d", i)))
+	}
+	assert.NoError(t, db.Save("nel", types.NELReport{URL: "https://example.com"}, "Mozilla/5.0 Firefox/128.0", "hash-nel"))
+
+	query := func(filter database.ReportFilter) database.ReportPage {
+		t.Helper()
+		page, err := db.Query(filter)
+		assert.NoError(t, err)
+		return page
+	}
+
+	assert.Len(t, query(database.ReportFilter{}).Reports, 4)
+	assert.Len(t, query(database.ReportFilter{Type: "csp"}).Reports, 3)
+	assert.Len(t, query(database.ReportFilter{Type: "csp", UserAgent: "firefox"}).Reports, 1)
+	assert.Len(t, query(database.ReportFilter{UserAgent: "100%"}).Reports, 0)
+	assert.Len(t, query(database.ReportFilter{Fields: map[string]string{"body.effectiveDirective": "script-src"}}).Reports, 2)
+	assert.Len(t, query(database.ReportFilter{Fields: map[string]string{"body.statusCode": "200"}}).Reports, 1)
+
+	page := query(database.ReportFilter{Fields: map[string]string{"body.blockedURL": "https://cdn.example/a.png"}})
+	if assert.Len(t, page.Reports, 1) {
+		stored := page.Reports[0]
+		assert.Equal(t, "csp", stored.ReportType)
+		assert.Equal(t, "Mozilla/5.0 Chrome/126.0", stored.UserAgent)
+		assert.Equal(t, "hash-1", stored.Hash)
+		assert.JSONEq(t, `{"url":"https://example.com","body":{"effectiveDirective":"img-src","blockedURL":"https://cdn.example/a.png"}}`, string(stored.Data))
+		assert.False(t, stored.CreatedAt.IsZero())
+	}
+
+	assert.Len(t, query(database.ReportFilter{Hash: "hash-nel"}).Reports, 1)
+	assert.Len(t, query(database.ReportFilter{Directive: "script-src"}).Reports, 2)
+
+	hourAgo := time.Now().Add(-time.Hour)
+	assert.Len(t, query(database.ReportFilter{From: hourAgo}).Reports, 4)
+	assert.Len(t, query(database.ReportFilter{To: hourAgo}).Reports, 0)
+
+	// Paginate through all reports two at a time.
+	seen := map[string]bool{}
+	filter := database.ReportFilter{Limit: 2}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3)
+		page := query(filter)
+		for _, r := range page.Reports {
+			assert.False(t, seen[r.ID], "report %s returned twice", r.ID)
+			seen[r.ID] = true
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	assert.Len(t, seen, 4)
+}
+
+func TestQueryReports_InvalidFilter(t *testing.T) {
+	db := dbtesting.GetDBForTest(t)
+
+	_, err := db.Query(database.ReportFilter{Fields: map[string]string{`body") OR 1=1 --`: "x"}})
+	assert.Error(t, err)
+
+	_, err = db.Query(database.ReportFilter{Cursor: "not-a-ulid"})
+	assert.Error(t, err)
+
+	_, err = db.Query(database.ReportFilter{Limit: database.MaxQueryLimit + 1})
+	assert.Error(t, err)
+}
+
+func TestGetReport(t *testing.T) {
+	db := dbtesting.GetDBForTest(t)
+
+	report := types.NELReport{URL: "https://example.com", Body: types.NELReportBody{Phase: "dns"}}
+	assert.NoError(t, db.Save("nel", report, "test-agent", "hash"))
+
+	page, err := db.Query(database.ReportFilter{})
+	require.NoError(t, err)
+	require.Len(t, page.Reports, 1)
+
+	stored, err := db.Get(page.Reports[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, page.Reports[0], stored)
+	assert.Equal(t, "nel", stored.ReportType)
+	assert.JSONEq(t, `{"url":"https://example.com","body":{"phase":"dns"}}`, string(stored.Data))
+
+	_, err = db.Get("01J00000000000000000000000")
+	assert.Equal(t, database.ErrReportNotFound, err)
+}
+
+func TestStats(t *testing.T) {
+	db := dbtesting.GetDBForTest(t)
+
+	firefox := "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"
+	chrome := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+
+	csp := func(directive, blockedURL, documentURL string) types.CSPReport {
+		return types.CSPReport{Body: types.CSPReportBody{EffectiveDirective: directive, BlockedURL: blockedURL, DocumentURL: documentURL}}
+	}
+	require.NoError(t, db.Save("csp", csp("script-src", "https://cdn.example/a.js", "https://example.com/checkout?step=1"), firefox, "h1"))
+	require.NoError(t, db.Save("csp", csp("script-src", "https://cdn.example/a.js", "https://example.com/checkout?step=1"), chrome, "h1"))
+	require.NoError(t, db.Save("csp", csp("script-src", "inline", "https://example.com/"), chrome, "h2"))
+	require.NoError(t, db.Save("csp", csp("img-src", "https://img.example/a.png", "https://example.com/checkout"), chrome, "h3"))
+	require.NoError(t, db.Save("nel", types.NELReport{URL: "https://example.com/api"}, "curl/8.0", "h4"))
+
+	stats := func(query database.StatsQuery) []database.StatsGroup {
+		t.Helper()
+		groups, err := db.Stats(query)
+		require.NoError(t, err)
+		return groups
+	}
+
+	assert.Equal(t, []database.StatsGroup{
+		{Value: "csp", Count: 4, Reports: 3},
+		{Value: "nel", Count: 1, Reports: 1},
+	}, stats(database.StatsQuery{GroupBy: database.GroupByType}))
+
+	assert.Equal(t, []database.StatsGroup{
+		{Value: "script-src", Count: 3, Reports: 2},
+		{Value: "img-src", Count: 1, Reports: 1},
+	}, stats(database.StatsQuery{GroupBy: database.GroupByDirective, Type: "csp"}))
+
+	assert.Equal(t, []database.StatsGroup{
+		{Value: "cdn.example", Count: 2, Reports: 1},
+	}, stats(database.StatsQuery{GroupBy: database.GroupByBlockedHost, Type: "csp", Limit: 1}))
+
+	assert.ElementsMatch(t, []database.StatsGroup{
+		{Value: "cdn.example", Count: 2, Reports: 1},
+		{Value: "img.example", Count: 1, Reports: 1},
+		{Value: "inline", Count: 1, Reports: 1},
+		{Value: "", Count: 1, Reports: 1},
+	}, stats(database.StatsQuery{GroupBy: database.GroupByBlockedHost}))
+
+	assert.Equal(t, []database.StatsGroup{
+		{Value: "/checkout", Count: 3, Reports: 2},
+		{Value: "/", Count: 1, Reports: 1},
+		{Value: "/api", Count: 1, Reports: 1},
+	}, stats(database.StatsQuery{GroupBy: database.GroupByDocumentPath}))
+
+	// The browser is derived from the first user agent of a report
+	assert.Equal(t, []database.StatsGroup{
+		{Value: "Chrome", Count: 2, Reports: 2},
+		{Value: "Firefox", Count: 2, Reports: 1},
+		{Value: "Other", Count: 1, Reports: 1},
+	}, stats(database.StatsQuery{GroupBy: database.GroupByBrowser}))
+
+	// Time buckets are chronological and only count new reports, not
+	// occurrences; the reports may straddle a bucket boundary
+	for unit, pattern := range map[string]string{
+		database.GroupByDay:    `^\d{4}-\d{2}-\d{2}$`,
+		database.GroupByHour:   `^\d{4}-\d{2}-\d{2} \d{2}:00$`,
+		database.GroupByMinute: `^\d{4}-\d{2}-\d{2} \d{2}:\d{2}$`,
+	} {
+		var total int64
+		groups := stats(database.StatsQuery{GroupBy: unit})
+		for i, g := range groups {
+			assert.Regexp(t, pattern, g.Value)
+			if i > 0 {
+				assert.Less(t, groups[i-1].Value, g.Value)
+			}
+			assert.Zero(t, g.Count)
+			total += g.Reports
+		}
+		assert.Equal(t, int64(4), total, unit)
+	}
+
+	assert.Empty(t, stats(database.StatsQuery{GroupBy: database.GroupByType, To: time.Now().Add(-time.Hour)}))
+	assert.Len(t, stats(database.StatsQuery{GroupBy: database.GroupByType, From: time.Now().Add(-time.Hour)}), 2)
+
+	_, err := db.Stats(database.StatsQuery{GroupBy: "data; DROP TABLE reports"})
+	assert.Error(t, err)
+}
+
+func TestIssues(t *testing.T) {
+	db := dbtesting.GetDBForTest(t)
+
+	csp := func(directive, blockedURL, documentURL string) types.CSPReport {
+		return types.CSPReport{Body: types.CSPReportBody{EffectiveDirective: directive, BlockedURL: blockedURL, DocumentURL: documentURL}}
+	}
+	start := time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+	record := func(report types.Report, hash string, at time.Time) {
+		t.Helper()
+		require.NoError(t, db.Record(database.NewOccurrence(report.Type(), report, "UA", hash, at)))
+	}
+
+	// The same blocked origin on different documents and paths is one issue
+	record(csp("script-src", "https://cdn.example/a.js", "https://example.com/a"), "h1", start)
+	record(csp("script-src", "https://CDN.example/b.js?v=2", "https://example.com/b"), "h2", start.Add(time.Minute))
+	record(csp("script-src", "https://cdn.example/a.js", "https://example.com/a"), "h1", start.Add(2*time.Minute))
+	record(csp("img-src", "https://cdn.example/a.png", "https://example.com/a"), "h3", start)
+	record(types.NELReport{URL: "https://example.com/api"}, "h4", start)
+
+	page, err := db.Issues(database.IssueFilter{})
+	require.NoError(t, err)
+	require.Len(t, page.Issues, 3)
+
+	page, err = db.Issues(database.IssueFilter{Directive: "script-src"})
+	require.NoError(t, err)
+	require.Len(t, page.Issues, 1)
+	issue := page.Issues[0]
+	assert.Equal(t, "csp", issue.ReportType)
+	assert.Equal(t, "https://cdn.example", issue.BlockedOrigin)
+	assert.Equal(t, database.IssueOpen, issue.Status)
+	assert.Equal(t, 3, issue.Occurrences)
+	assert.True(t, start.Equal(issue.FirstSeen), "first seen %s", issue.FirstSeen)
+	assert.True(t, start.Add(2*time.Minute).Equal(issue.LastSeen), "last seen %s", issue.LastSeen)
+
+	reports, err := db.Query(database.ReportFilter{Issue: issue.ID})
+	require.NoError(t, err)
+	assert.Len(t, reports.Reports, 2)
+	for _, r := range reports.Reports {
+		assert.Equal(t, issue.ID, r.IssueID)
+	}
+
+	// Reports without a directive or blocked URL get an issue of their own
+	page, err = db.Issues(database.IssueFilter{Type: "nel"})
+	require.NoError(t, err)
+	require.Len(t, page.Issues, 1)
+	assert.Equal(t, "h4", page.Issues[0].Fingerprint)
+
+	// Triage
+	notes := "Third-party widget, tracked in JIRA-1"
+	resolved, err := db.UpdateIssue(issue.ID, database.IssueUpdate{Status: database.IssueResolved, Notes: &notes})
+	require.NoError(t, err)
+	assert.Equal(t, database.IssueResolved, resolved.Status)
+	assert.Equal(t, notes, resolved.Notes)
+
+	stored, err := db.GetIssue(issue.ID)
+	require.NoError(t, err)
+	assert.Equal(t, database.IssueResolved, stored.Status)
+	assert.Equal(t, notes, stored.Notes)
+
+	// Updating only the notes keeps the status
+	notes = "Widget removed"
+	stored, err = db.UpdateIssue(issue.ID, database.IssueUpdate{Notes: &notes})
+	require.NoError(t, err)
+	assert.Equal(t, database.IssueResolved, stored.Status)
+
+	// Occurrences seen before the issue was resolved do not reopen it
+	record(csp("script-src", "https://cdn.example/c.js", "https://example.com/c"), "h5", start.Add(3*time.Minute))
+	stored, err = db.GetIssue(issue.ID)
+	require.NoError(t, err)
+	assert.Equal(t, database.IssueResolved, stored.Status)
+	assert.Equal(t, 4, stored.Occurrences)
+
+	// A new report reopens a resolved issue
+	reopenedAt := time.Now().Add(time.Minute).UTC()
+	record(csp("script-src", "https://cdn.example/a.js", "https://example.com/a"), "h1", reopenedAt)
+	stored, err = db.GetIssue(issue.ID)
+	require.NoError(t, err)
+	assert.Equal(t, database.IssueOpen, stored.Status)
+	assert.True(t, reopenedAt.Equal(stored.StatusChangedAt), "status changed at %s", stored.StatusChangedAt)
+	assert.Equal(t, "Widget removed", stored.Notes)
+
+	// Ignored issues stay ignored
+	_, err = db.UpdateIssue(issue.ID, database.IssueUpdate{Status: database.IssueIgnored})
+	require.NoError(t, err)
+	record(csp("script-src", "https://cdn.example/a.js", "https://example.com/a"), "h1", reopenedAt.Add(time.Hour))
+	stored, err = db.GetIssue(issue.ID)
+	require.NoError(t, err)
+	assert.Equal(t, database.IssueIgnored, stored.Status)
+
+	page, err = db.Issues(database.IssueFilter{Status: database.IssueIgnored})
+	require.NoError(t, err)
+	assert.Len(t, page.Issues, 1)
+
+	_, err = db.UpdateIssue(issue.ID, database.IssueUpdate{Status: "closed"})
+	assert.Error(t, err)
+	_, err = db.GetIssue("01J00000000000000000000000")
+	assert.Equal(t, database.ErrIssueNotFound, err)
+	_, err = db.UpdateIssue("01J00000000000000000000000", database.IssueUpdate{Status: database.IssueResolved})
+	assert.Equal(t, database.ErrIssueNotFound, err)
+	_, err = db.Issues(database.IssueFilter{Status: "closed"})
+	assert.Error(t, err)
+}
+
+func TestDedupKey(t *testing.T) {
+	at := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	assert.Equal(t, "hash", database.DedupKey("hash", at, 0))
+	assert.Equal(t, "hash/1735776000", database.DedupKey("hash", at, 24*time.Hour))
+	assert.Equal(t, "hash/1735819200", database.DedupKey("hash", at, 6*time.Hour))
+	assert.Equal(t, database.DedupKey("hash", at, time.Hour), database.DedupKey("hash", at.Add(50*time.Minute).In(time.FixedZone("", 3600)), time.Hour))
+}
+
+func TestRecordOccurrence_DedupWindow(t *testing.T) {
+	t.Setenv("DEDUP_WINDOW_HOURS", "24")
+	db := dbtesting.GetDBForTest(t)
+	assert.Equal(t, 24*time.Hour, db.DedupWindow())
+
+	report := types.NELReport{URL: "https://example.com", Body: types.NELReportBody{Phase: "dns"}}
+	day := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	// The window slides with each duplicate, across midnight
+	for _, at := range []time.Time{day.Add(10 * time.Hour), day.Add(20 * time.Hour), day.Add(25 * time.Hour), day.Add(50 * time.Hour)} {
+		require.NoError(t, db.Record(database.NewOccurrence("nel", report, "UA", "hash", at)))
+	}
+
+	// Reports after the window are stored as a new report with the same hash
+	page, err := db.Query(database.ReportFilter{Hash: "hash"})
+	require.NoError(t, err)
+	require.Len(t, page.Reports, 2)
+	assert.Equal(t, 1, page.Reports[0].Occurrences)
+	assert.True(t, day.Add(50*time.Hour).Equal(page.Reports[0].FirstSeen), "first seen %s", page.Reports[0].FirstSeen)
+	assert.Equal(t, 3, page.Reports[1].Occurrences)
+	assert.True(t, day.Add(25*time.Hour).Equal(page.Reports[1].LastSeen), "last seen %s", page.Reports[1].LastSeen)
+
+	// A late occurrence is counted on the report seen within the window
+	require.NoError(t, db.Record(database.NewOccurrence("nel", report, "UA", "hash", day.Add(40*time.Hour))))
+	page, err = db.Query(database.ReportFilter{Hash: "hash"})
+	require.NoError(t, err)
+	require.Len(t, page.Reports, 2)
+	assert.Equal(t, 2, page.Reports[0].Occurrences)
+}
+
+func TestNewSQLiteDB_WAL(t *testing.T) {
+	db, err := database.NewSQLiteDB(config.SQLite{Database: filepath.Join(t.TempDir(), "wal.db")}, 0)
+	require.NoError(t, err)
+	sqlite := db.(*database.SQLiteDB)
+	defer sqlite.DB.Close()
+
+	var journalMode string
+	require.NoError(t, sqlite.DB.QueryRow("PRAGMA journal_mode").Scan(&journalMode))
+	assert.Equal(t, "wal", journalMode)
+	var busyTimeout int
+	require.NoError(t, sqlite.DB.QueryRow("PRAGMA busy_timeout").Scan(&busyTimeout))
+	assert.Equal(t, 5000, busyTimeout)
+}
+
+func TestPurge(t *testing.T) {
+	db := dbtesting.GetDBForTest(t)
+
+	now := time.Now()
+	record := func(reportType, hash string, age time.Duration) {
+		report := types.NELReport{URL: "https://example.com/" + hash}
+		require.NoError(t, db.Record(database.NewOccurrence(reportType, report, "UA", hash, now.Add(-age))))
+	}
+	day := 24 * time.Hour
+	record("csp", "csp-old", 40*day)
+	record("csp", "csp-recent", day)
+	record("nel", "nel-old", 100*day)
+	record("nel", "nel-recent", 40*day)
+	record("deprecation", "deprecation-old", 100*day)
+	record("deprecation", "deprecation-recent", 10*day)
+
+	// The triage state of ignored issues survives their reports
+	page, err := db.Query(database.ReportFilter{Hash: "deprecation-old"})
+	require.NoError(t, err)
+	require.Len(t, page.Reports, 1)
+	_, err = db.UpdateIssue(page.Reports[0].IssueID, database.IssueUpdate{Status: database.IssueIgnored})
+	require.NoError(t, err)
+
+	policy := database.RetentionPolicy{
+		MaxAge:       90 * day,
+		MaxAgeByType: map[string]time.Duration{"csp": 30 * day, "nel": 0},
+		BatchSize:    1,
+	}
+	result, err := db.Purge(policy)
+	require.NoError(t, err)
+	assert.Equal(t, database.PurgeResult{Expired: 2, Issues: 1}, result)
+	assert.Equal(t, []string{"deprecation-recent", "nel-recent", "nel-old", "csp-recent"}, storedHashes(t, db))
+
+	issues, err := db.Issues(database.IssueFilter{})
+	require.NoError(t, err)
+	assert.Len(t, issues.Issues, 5)
+
+	// The least recently seen reports beyond the maximum are purged
+	policy.MaxReports = 2
+	result, err = db.Purge(policy)
+	require.NoError(t, err)
+	assert.Equal(t, database.PurgeResult{Excess: 2}, result)
+	assert.Equal(t, []string{"deprecation-recent", "csp-recent"}, storedHashes(t, db))
+
+	// Nothing left to purge
+	result, err = db.Purge(policy)
+	require.NoError(t, err)
+	assert.Zero(t, result.Total())
+}
+
+// storedHashes returns the hashes of the stored reports, newest first.
+func storedHashes(t *testing.T, db database.Store) []string {
+	t.Helper()
+	page, err := db.Query(database.ReportFilter{})
+	require.NoError(t, err)
+	hashes := make([]string, len(page.Reports))
+	for i, r := range page.Reports {
+		hashes[i] = r.Hash
+	}
+	return hashes
+}
+
+func TestExport(t *testing.T) {
+	db := dbtesting.GetDBForTest(t)
+
+	now := time.Now()
+	for i, hash := range []string{"a", "b", "c"} {
+		report := types.NELReport{URL: "https://example.com/" + hash}
+		require.NoError(t, db.Record(database.NewOccurrence("nel", report, "UA", hash, now.Add(time.Duration(i)*time.Second))))
+	}
+	require.NoError(t, db.Record(database.NewOccurrence("deprecation", types.DeprecationReport{URL: "https://example.com"}, "UA", "d", now)))
+
+	// The limit of the filter does not apply to exports
+	var hashes []string
+	err := db.Export(database.ReportFilter{Type: "nel", Limit: 1}, func(r database.StoredReport) error {
+		assert.JSONEq(t, `{"url":"https://example.com/`+r.Hash+`","body":{}}`, string(r.Data))
+		hashes = append(hashes, r.Hash)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "b", "a"}, hashes)
+
+	// Errors returned by fn stop the export
+	calls := 0
+	err = db.Export(database.ReportFilter{}, func(database.StoredReport) error {
+		calls++
+		return errors.New("client gone")
+	})
+	assert.EqualError(t, err, "client gone")
+	assert.Equal(t, 1, calls)
+
+	assert.Error(t, db.Export(database.ReportFilter{Cursor: "invalid"}, func(database.StoredReport) error { return nil }))
+}
+
+func TestNew_Postgres(t *testing.T) {
+	cfg := config.NewDB()
+	cfg.Connection = "postgres"
+	cfg.Postgres = config.Postgres{Host: "127.0.0.1", Port: 1, User: "postgres", Database: "reports", SSLMode: "disable"}
+
+	// The postgres backend is selected, and fails to connect
+	_, err := database.New(cfg)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "unsupported database connection")
+}
+
+// stubDB is a database backend registered by tests.
+type stubDB struct {
+	database.DB
+}
+
+func TestNew_RegisteredBackend(t *testing.T) {
+	backend := &stubDB{}
+	var received *config.DB
+	database.RegisterDB("test-backend", func(cfg *config.DB) (database.DB, error) {
+		received = cfg
+		return backend, nil
+	})
+
+	cfg := &config.DB{Connection: "test-backend", DedupWindow: time.Hour}
+	db, err := database.New(cfg)
+	require.NoError(t, err)
+	assert.Same(t, backend, db)
+	assert.Same(t, cfg, received)
+
+	_, err = database.New(&config.DB{Connection: "unknown"})
+	assert.EqualError(t, err, "unsupported database connection: unknown")
+
+	// The built-in backends are registered
+	for _, name := range []string{"sqlite", "mysql", "postgres"} {
+		_, err := database.GetDBBuilder(name)
+		assert.NoError(t, err, name)
+	}
+}
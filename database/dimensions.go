@@ -0,0 +1,80 @@
+package database
+
+import (
+	"database/sql"
+	"net/url"
+	"unicode/utf8"
+
+	"github.com/vinsonio/security-report-collector/internal/util"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// maxDimensionLength is the length of the promoted dimension columns.
+const maxDimensionLength = 255
+
+// dimensions are the values stored alongside a report that statistics are grouped by.
+type dimensions struct {
+	Directive    sql.NullString
+	BlockedHost  sql.NullString
+	DocumentPath sql.NullString
+	Browser      sql.NullString
+}
+
+// newDimensions derives the dimensions of a report. Reports that do not
+// implement types.Summarizer only get a browser family.
+func newDimensions(report types.Report, userAgent string) dimensions {
+	d := dimensions{Browser: nullString(util.BrowserFamily(userAgent))}
+
+	summarizer, ok := report.(types.Summarizer)
+	if !ok {
+		return d
+	}
+	summary := summarizer.Summary()
+	d.Directive = nullString(summary.Directive)
+	d.BlockedHost = nullString(urlHost(summary.BlockedURL))
+	d.DocumentPath = nullString(urlPath(summary.DocumentURL))
+	return d
+}
+
+// urlHost returns the host of an absolute URL. Values without a host, such as
+// the "inline" and "eval" keywords of CSP or data: URLs, return their scheme or
+// the value itself.
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if u.Host != "" {
+		return u.Hostname()
+	}
+	if u.Scheme != "" {
+		return u.Scheme
+	}
+	return rawURL
+}
+
+// urlPath returns the path of a URL, or "/" for URLs without one.
+func urlPath(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
+
+// nullString returns s truncated to the dimension column length, or NULL if s is empty.
+func nullString(s string) sql.NullString {
+	if s == "" {
+		return sql.NullString{}
+	}
+	if len(s) > maxDimensionLength {
+		s = s[:maxDimensionLength]
+		for !utf8.ValidString(s) {
+			s = s[:len(s)-1]
+		}
+	}
+	return sql.NullString{String: s, Valid: true}
+}
@@ -0,0 +1,15 @@
+package database
+
+import (
+	"github.com/vinsonio/security-report-collector/config"
+)
+
+// New creates the database backend registered under the connection of the
+// configuration (see RegisterDB).
+func New(cfg *config.DB) (DB, error) {
+	builder, err := GetDBBuilder(cfg.Connection)
+	if err != nil {
+		return nil, err
+	}
+	return builder(cfg)
+}
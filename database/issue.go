@@ -0,0 +1,331 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/vinsonio/security-report-collector/internal/util"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// Issue triage states.
+const (
+	IssueOpen         = "open"
+	IssueAcknowledged = "acknowledged"
+	IssueIgnored      = "ignored"
+	IssueResolved     = "resolved"
+)
+
+// MaxIssueNotesLength is the maximum length of the notes of an issue.
+const MaxIssueNotesLength = 10000
+
+// ErrIssueNotFound is returned when an issue does not exist.
+var ErrIssueNotFound = errors.New("issue not found")
+
+// Issue groups the stored reports sharing a fingerprint looser than the report
+// hash, e.g. the same blocked origin on many documents, and tracks their triage state.
+type Issue struct {
+	ID          string `json:"id"`
+	Fingerprint string `json:"fingerprint"`
+	ReportType  string `json:"report_type"`
+	// Directive and BlockedOrigin are the values the fingerprint was derived from.
+	Directive     string `json:"directive"`
+	BlockedOrigin string `json:"blocked_origin"`
+	// Status is the triage state, one of the Issue constants.
+	Status string `json:"status"`
+	Notes  string `json:"notes"`
+	// Occurrences is the number of times a report of the issue was received.
+	Occurrences int       `json:"occurrences"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+	// StatusChangedAt is when the status was last changed, by triage or by a
+	// new report reopening a resolved issue.
+	StatusChangedAt time.Time `json:"status_changed_at"`
+}
+
+// IssueFilter describes which issues to return. Zero values are ignored.
+type IssueFilter struct {
+	// Status matches the triage state.
+	Status string
+	// Type matches the stored report type, e.g. "csp".
+	Type string
+	// Directive matches the violated directive or feature.
+	Directive string
+	// Cursor returns issues created before the issue with this ID.
+	Cursor string
+	// Limit is the maximum number of issues to return.
+	Limit int
+}
+
+// IssuePage is a page of issues, newest first.
+type IssuePage struct {
+	Issues []Issue `json:"issues"`
+	// NextCursor is the cursor for the next page, empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// IssueUpdate changes the triage state of an issue. Zero values are left unchanged.
+type IssueUpdate struct {
+	Status string  `json:"status"`
+	Notes  *string `json:"notes"`
+}
+
+// issueColumns are the columns selected into an Issue.
+const issueColumns = "id, fingerprint, report_type, directive, blocked_origin, status, notes, occurrences, first_seen, last_seen, status_changed_at"
+
+// ValidIssueStatus reports whether status is one of the triage states.
+func ValidIssueStatus(status string) bool {
+	switch status {
+	case IssueOpen, IssueAcknowledged, IssueIgnored, IssueResolved:
+		return true
+	}
+	return false
+}
+
+// Validate checks the filter for malformed values.
+func (f IssueFilter) Validate() error {
+	if f.Status != "" && !ValidIssueStatus(f.Status) {
+		return fmt.Errorf("invalid status: %q", f.Status)
+	}
+	if f.Limit < 0 || f.Limit > MaxQueryLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxQueryLimit)
+	}
+	if f.Cursor != "" {
+		if _, err := ulid.ParseStrict(f.Cursor); err != nil {
+			return fmt.Errorf("invalid cursor: %w", err)
+		}
+	}
+	return nil
+}
+
+// limit returns the page size of the filter.
+func (f IssueFilter) limit() int {
+	if f.Limit == 0 {
+		return DefaultQueryLimit
+	}
+	return f.Limit
+}
+
+// Validate checks the update for malformed values.
+func (u IssueUpdate) Validate() error {
+	if u.Status != "" && !ValidIssueStatus(u.Status) {
+		return fmt.Errorf("invalid status: %q", u.Status)
+	}
+	if u.Notes != nil && len(*u.Notes) > MaxIssueNotesLength {
+		return fmt.Errorf("notes must be at most %d bytes", MaxIssueNotesLength)
+	}
+	return nil
+}
+
+// issueKey identifies the issue a report belongs to.
+type issueKey struct {
+	Fingerprint   string
+	Directive     sql.NullString
+	BlockedOrigin sql.NullString
+}
+
+// newIssueKey derives the issue of a report from its type, directive and
+// blocked origin, so that a blocked resource is one issue regardless of the
+// document or line it was reported on. Reports without a directive or blocked
+// URL (see types.Summarizer) get an issue of their own, keyed by the report hash.
+func newIssueKey(reportType string, report types.Report, hash string) (issueKey, error) {
+	var summary types.Summary
+	if summarizer, ok := report.(types.Summarizer); ok {
+		summary = summarizer.Summary()
+	}
+
+	key := issueKey{
+		Directive:     nullString(summary.Directive),
+		BlockedOrigin: nullString(urlOrigin(summary.BlockedURL)),
+	}
+	if !key.Directive.Valid && !key.BlockedOrigin.Valid {
+		key.Fingerprint = hash
+		return key, nil
+	}
+
+	data, err := util.StableMarshal(map[string]string{
+		"report_type":    reportType,
+		"directive":      key.Directive.String,
+		"blocked_origin": key.BlockedOrigin.String,
+	})
+	if err != nil {
+		return issueKey{}, err
+	}
+	sum := sha256.Sum256(data)
+	key.Fingerprint = hex.EncodeToString(sum[:])
+	return key, nil
+}
+
+// urlOrigin returns the origin of an absolute URL. Values without a host, such
+// as the "inline" and "eval" keywords of CSP, are handled like urlHost.
+func urlOrigin(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return urlHost(rawURL)
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// execer is implemented by *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// recordIssue adds an occurrence to its issue, creating the issue if needed,
+// and returns the issue ID. upsertQuery takes the id, fingerprint, report_type,
+// directive, blocked_origin, occurrences, first_seen, last_seen and
+// status_changed_at values in that order. A resolved issue is reopened by
+// occurrences seen after it was resolved.
+func recordIssue(db execer, o Occurrence, count int, upsertQuery string) (string, error) {
+	key, err := newIssueKey(o.ReportType, o.Report, o.Hash)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := ulid.New(ulid.Timestamp(time.Now()), rand.Reader)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = db.Exec(upsertQuery, id.String(), key.Fingerprint, o.ReportType, key.Directive, key.BlockedOrigin, count, o.FirstSeen.UTC(), o.LastSeen.UTC(), o.FirstSeen.UTC())
+	if err != nil {
+		return "", err
+	}
+
+	var issueID string
+	if err := db.QueryRow("SELECT id FROM issues WHERE fingerprint = ?", key.Fingerprint).Scan(&issueID); err != nil {
+		return "", err
+	}
+
+	_, err = db.Exec("UPDATE issues SET status = ?, status_changed_at = ? WHERE id = ? AND status = ? AND status_changed_at < ?",
+		IssueOpen, o.LastSeen.UTC(), issueID, IssueResolved, o.LastSeen.UTC())
+	if err != nil {
+		return "", err
+	}
+
+	return issueID, nil
+}
+
+// queryIssues returns a page of issues matching the filter, newest first.
+func queryIssues(db *sql.DB, f IssueFilter) (IssuePage, error) {
+	if err := f.Validate(); err != nil {
+		return IssuePage{}, err
+	}
+
+	var conditions []string
+	var args []interface{}
+	if f.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, f.Status)
+	}
+	if f.Type != "" {
+		conditions = append(conditions, "report_type = ?")
+		args = append(args, f.Type)
+	}
+	if f.Directive != "" {
+		conditions = append(conditions, "directive = ?")
+		args = append(args, f.Directive)
+	}
+	if f.Cursor != "" {
+		conditions = append(conditions, "id < ?")
+		args = append(args, f.Cursor)
+	}
+
+	query := "SELECT " + issueColumns + " FROM issues"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	// Fetch one extra row to find out whether there is a next page.
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, f.limit()+1)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return IssuePage{}, err
+	}
+	defer rows.Close()
+
+	page := IssuePage{Issues: []Issue{}}
+	for rows.Next() {
+		issue, err := scanIssue(rows)
+		if err != nil {
+			return IssuePage{}, err
+		}
+		page.Issues = append(page.Issues, issue)
+	}
+	if err := rows.Err(); err != nil {
+		return IssuePage{}, err
+	}
+
+	if limit := f.limit(); len(page.Issues) > limit {
+		page.Issues = page.Issues[:limit]
+		page.NextCursor = page.Issues[limit-1].ID
+	}
+	return page, nil
+}
+
+// scanIssue reads a single row selected with issueColumns.
+func scanIssue(row interface {
+	Scan(dest ...interface{}) error
+}) (Issue, error) {
+	var i Issue
+	var directive, blockedOrigin, notes sql.NullString
+	var firstSeen, lastSeen, statusChangedAt sql.NullTime
+	err := row.Scan(&i.ID, &i.Fingerprint, &i.ReportType, &directive, &blockedOrigin, &i.Status, &notes,
+		&i.Occurrences, &firstSeen, &lastSeen, &statusChangedAt)
+	if err != nil {
+		return Issue{}, err
+	}
+	i.Directive = directive.String
+	i.BlockedOrigin = blockedOrigin.String
+	i.Notes = notes.String
+	i.FirstSeen = firstSeen.Time
+	i.LastSeen = lastSeen.Time
+	i.StatusChangedAt = statusChangedAt.Time
+	return i, nil
+}
+
+// getIssue returns the issue with the given ID.
+func getIssue(db *sql.DB, id string) (Issue, error) {
+	i, err := scanIssue(db.QueryRow("SELECT "+issueColumns+" FROM issues WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Issue{}, ErrIssueNotFound
+	}
+	return i, err
+}
+
+// updateIssue applies an update to the issue with the given ID and returns the updated issue.
+func updateIssue(db *sql.DB, id string, u IssueUpdate) (Issue, error) {
+	if err := u.Validate(); err != nil {
+		return Issue{}, err
+	}
+
+	issue, err := getIssue(db, id)
+	if err != nil {
+		return Issue{}, err
+	}
+
+	if u.Status != "" && u.Status != issue.Status {
+		issue.Status = u.Status
+		issue.StatusChangedAt = time.Now().UTC()
+	}
+	if u.Notes != nil {
+		issue.Notes = *u.Notes
+	}
+
+	_, err = db.Exec("UPDATE issues SET status = ?, notes = ?, status_changed_at = ? WHERE id = ?",
+		issue.Status, issue.Notes, issue.StatusChangedAt, id)
+	if err != nil {
+		return Issue{}, err
+	}
+	return issue, nil
+}
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/vinsonio/security-report-collector/database/migrations"
+)
+
+// runMigrations applies the embedded migrations of a dialect to the database of
+// driver. Each dialect has its own directory of migrations, so that they can
+// use the SQL of their database engine.
+func runMigrations(driver database.Driver, dialect, databaseName string) error {
+	source, err := iofs.New(migrations.FS, dialect)
+	if err != nil {
+		return err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, databaseName, driver)
+	if err != nil {
+		return err
+	}
+
+	if err = m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	return nil
+}
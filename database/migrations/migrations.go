@@ -0,0 +1,11 @@
+// Package migrations embeds the database migrations, so that the server
+// binary can migrate its database without the migration files on disk.
+package migrations
+
+import "embed"
+
+// FS holds the migrations of each dialect in a directory named after it:
+// sqlite, mysql and postgres.
+//
+//go:embed sqlite/*.sql mysql/*.sql postgres/*.sql
+var FS embed.FS
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"io/fs"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFS_DialectsInStep(t *testing.T) {
+	sqlite, err := fs.Glob(FS, "sqlite/*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, sqlite)
+
+	for _, dialect := range []string{"mysql", "postgres"} {
+		files, err := fs.Glob(FS, dialect+"/*.sql")
+		require.NoError(t, err)
+		assert.Equal(t, baseNames(sqlite), baseNames(files), "migrations of %s", dialect)
+	}
+}
+
+// baseNames returns the last elements of paths.
+func baseNames(paths []string) []string {
+	var names []string
+	for _, p := range paths {
+		names = append(names, path.Base(p))
+	}
+	return names
+}
@@ -0,0 +1,4 @@
+ALTER TABLE reports DROP COLUMN user_agents;
+ALTER TABLE reports DROP COLUMN last_seen;
+ALTER TABLE reports DROP COLUMN first_seen;
+ALTER TABLE reports DROP COLUMN occurrences;
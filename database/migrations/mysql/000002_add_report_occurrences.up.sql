@@ -0,0 +1,5 @@
+ALTER TABLE reports ADD COLUMN occurrences INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE reports ADD COLUMN first_seen TIMESTAMP NULL;
+ALTER TABLE reports ADD COLUMN last_seen TIMESTAMP NULL;
+ALTER TABLE reports ADD COLUMN user_agents JSON NULL;
+UPDATE reports SET first_seen = created_at, last_seen = created_at, user_agents = JSON_ARRAY(user_agent);
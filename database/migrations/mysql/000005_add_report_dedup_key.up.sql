@@ -0,0 +1,23 @@
+CREATE TABLE reports_dedup (
+    id VARCHAR(255) NOT NULL PRIMARY KEY,
+    report_type VARCHAR(255) NOT NULL,
+    data JSON NOT NULL,
+    user_agent VARCHAR(255) NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    dedup_key VARCHAR(100) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    occurrences INTEGER NOT NULL DEFAULT 1,
+    first_seen TIMESTAMP NULL,
+    last_seen TIMESTAMP NULL,
+    user_agents JSON NULL,
+    directive VARCHAR(255) NULL,
+    blocked_host VARCHAR(255) NULL,
+    document_path VARCHAR(255) NULL,
+    browser VARCHAR(64) NULL,
+    issue_id VARCHAR(26) NULL
+);
+INSERT INTO reports_dedup (id, report_type, data, user_agent, hash, dedup_key, created_at, occurrences, first_seen, last_seen, user_agents, directive, blocked_host, document_path, browser, issue_id)
+SELECT id, report_type, data, user_agent, hash, hash, created_at, occurrences, first_seen, last_seen, user_agents, directive, blocked_host, document_path, browser, issue_id FROM reports;
+DROP TABLE reports;
+ALTER TABLE reports_dedup RENAME TO reports;
+CREATE INDEX reports_hash ON reports (hash);
@@ -0,0 +1,4 @@
+DROP INDEX reports_directive ON reports;
+DROP INDEX reports_blocked_host ON reports;
+DROP INDEX reports_document_path ON reports;
+DROP INDEX reports_browser ON reports;
@@ -0,0 +1,4 @@
+ALTER TABLE reports ADD COLUMN directive VARCHAR(255) NULL;
+ALTER TABLE reports ADD COLUMN blocked_host VARCHAR(255) NULL;
+ALTER TABLE reports ADD COLUMN document_path VARCHAR(255) NULL;
+ALTER TABLE reports ADD COLUMN browser VARCHAR(64) NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE reports DROP COLUMN issue_id;
+DROP TABLE IF EXISTS issues;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS issues (
+    id VARCHAR(26) NOT NULL PRIMARY KEY,
+    fingerprint VARCHAR(64) NOT NULL UNIQUE,
+    report_type VARCHAR(255) NOT NULL,
+    directive VARCHAR(255) NULL,
+    blocked_origin VARCHAR(255) NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    notes TEXT NULL,
+    occurrences INTEGER NOT NULL DEFAULT 0,
+    first_seen TIMESTAMPTZ NULL,
+    last_seen TIMESTAMPTZ NULL,
+    status_changed_at TIMESTAMPTZ NULL
+);
+ALTER TABLE reports ADD COLUMN issue_id VARCHAR(26) NULL;
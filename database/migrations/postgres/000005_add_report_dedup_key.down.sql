@@ -0,0 +1,5 @@
+-- Keep the earliest report of each hash
+DELETE FROM reports WHERE id NOT IN (SELECT MIN(id) FROM reports GROUP BY hash);
+DROP INDEX reports_hash;
+ALTER TABLE reports ADD CONSTRAINT reports_hash_key UNIQUE (hash);
+ALTER TABLE reports DROP COLUMN dedup_key;
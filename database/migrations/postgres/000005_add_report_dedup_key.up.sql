@@ -0,0 +1,6 @@
+ALTER TABLE reports ADD COLUMN dedup_key VARCHAR(100) NULL;
+UPDATE reports SET dedup_key = hash;
+ALTER TABLE reports ALTER COLUMN dedup_key SET NOT NULL;
+ALTER TABLE reports ADD CONSTRAINT reports_dedup_key_key UNIQUE (dedup_key);
+ALTER TABLE reports DROP CONSTRAINT reports_hash_key;
+CREATE INDEX reports_hash ON reports (hash);
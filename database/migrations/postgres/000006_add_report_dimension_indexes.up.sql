@@ -0,0 +1,4 @@
+CREATE INDEX reports_directive ON reports (directive);
+CREATE INDEX reports_blocked_host ON reports (blocked_host);
+CREATE INDEX reports_document_path ON reports (document_path);
+CREATE INDEX reports_browser ON reports (browser);
@@ -0,0 +1 @@
+DROP INDEX reports_issue_id;
@@ -0,0 +1 @@
+CREATE INDEX reports_issue_id ON reports (issue_id);
@@ -0,0 +1,4 @@
+ALTER TABLE reports DROP COLUMN browser;
+ALTER TABLE reports DROP COLUMN document_path;
+ALTER TABLE reports DROP COLUMN blocked_host;
+ALTER TABLE reports DROP COLUMN directive;
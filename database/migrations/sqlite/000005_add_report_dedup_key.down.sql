@@ -0,0 +1,23 @@
+CREATE TABLE reports_unique (
+    id VARCHAR(255) NOT NULL PRIMARY KEY,
+    report_type VARCHAR(255) NOT NULL,
+    data JSON NOT NULL,
+    user_agent VARCHAR(255) NOT NULL,
+    hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    occurrences INTEGER NOT NULL DEFAULT 1,
+    first_seen TIMESTAMP NULL,
+    last_seen TIMESTAMP NULL,
+    user_agents JSON NULL,
+    directive VARCHAR(255) NULL,
+    blocked_host VARCHAR(255) NULL,
+    document_path VARCHAR(255) NULL,
+    browser VARCHAR(64) NULL,
+    issue_id VARCHAR(26) NULL
+);
+-- Keep the earliest report of each hash
+INSERT INTO reports_unique (id, report_type, data, user_agent, hash, created_at, occurrences, first_seen, last_seen, user_agents, directive, blocked_host, document_path, browser, issue_id)
+SELECT id, report_type, data, user_agent, hash, created_at, occurrences, first_seen, last_seen, user_agents, directive, blocked_host, document_path, browser, issue_id FROM reports
+WHERE id IN (SELECT MIN(id) FROM reports GROUP BY hash);
+DROP TABLE reports;
+ALTER TABLE reports_unique RENAME TO reports;
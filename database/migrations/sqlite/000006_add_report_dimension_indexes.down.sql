@@ -0,0 +1,4 @@
+DROP INDEX reports_directive;
+DROP INDEX reports_blocked_host;
+DROP INDEX reports_document_path;
+DROP INDEX reports_browser;
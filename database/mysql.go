@@ -0,0 +1,165 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	migrate_mysql "github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/vinsonio/security-report-collector/config"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// MySQLDB is a MySQL-backed database implementation.
+type MySQLDB struct {
+	DB *sql.DB
+	// dedupWindow is how long reports with the same hash are counted as one
+	// report (see DedupKey); zero deduplicates forever.
+	dedupWindow time.Duration
+}
+
+func init() {
+	RegisterDB("mysql", func(cfg *config.DB) (DB, error) {
+		return NewMySQLDB(cfg.MySQL, cfg.DedupWindow)
+	})
+}
+
+// NewMySQLDB creates a new MySQLDB.
+func NewMySQLDB(cfg config.MySQL, dedupWindow time.Duration) (Store, error) {
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local&multiStatements=true",
+		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Database)
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = db.Ping(); err != nil {
+		return nil, err
+	}
+
+	return &MySQLDB{DB: db, dedupWindow: dedupWindow}, nil
+}
+
+// Migrate runs the database migrations.
+func (s *MySQLDB) Migrate() error {
+	var driver database.Driver
+	driver, err := migrate_mysql.WithInstance(s.DB, &migrate_mysql.Config{})
+	if err != nil {
+		return err
+	}
+
+	return runMigrations(driver, "mysql", "mysql")
+}
+
+// mysqlUpsertReport inserts a report or adds to the occurrences of the report with the same dedup key.
+const mysqlUpsertReport = `INSERT INTO reports (id, report_type, data, user_agent, hash, dedup_key, occurrences, first_seen, last_seen, user_agents, directive, blocked_host, document_path, browser, issue_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+	occurrences = occurrences + VALUES(occurrences),
+	first_seen = LEAST(COALESCE(first_seen, VALUES(first_seen)), VALUES(first_seen)),
+	last_seen = GREATEST(COALESCE(last_seen, VALUES(last_seen)), VALUES(last_seen)),
+	user_agents = VALUES(user_agents),
+	issue_id = COALESCE(issue_id, VALUES(issue_id))`
+
+// mysqlUpsertIssue inserts an issue or adds to the occurrences of the issue with the same fingerprint.
+const mysqlUpsertIssue = `INSERT INTO issues (id, fingerprint, report_type, directive, blocked_origin, occurrences, first_seen, last_seen, status_changed_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+	occurrences = occurrences + VALUES(occurrences),
+	first_seen = LEAST(first_seen, VALUES(first_seen)),
+	last_seen = GREATEST(last_seen, VALUES(last_seen))`
+
+// mysqlTimeBuckets are the formats of the stats time buckets.
+var mysqlTimeBuckets = map[string]string{
+	GroupByMinute: "%Y-%m-%d %H:%i",
+	GroupByHour:   "%Y-%m-%d %H:00",
+	GroupByDay:    "%Y-%m-%d",
+}
+
+// mysqlJSONExtract returns an SQL expression evaluating to the text value at a JSON path placeholder.
+func mysqlJSONExtract(path string) string {
+	return fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(data, %s))", path)
+}
+
+// Save saves a report to the database, or counts another occurrence if a report
+// with the same hash exists within the dedup window.
+func (s *MySQLDB) Save(reportType string, report types.Report, userAgent, hash string) error {
+	return s.Record(NewOccurrence(reportType, report, userAgent, hash, time.Now()))
+}
+
+// Record saves the report of an occurrence, or adds the occurrence to the report
+// with the same hash within the dedup window.
+func (s *MySQLDB) Record(occurrence Occurrence) error {
+	return recordOccurrence(s.DB, occurrence, s.dedupWindow, upsertQueries{report: mysqlUpsertReport, issue: mysqlUpsertIssue})
+}
+
+// DedupWindow returns how long reports with the same hash are counted as one report.
+func (s *MySQLDB) DedupWindow() time.Duration {
+	return s.dedupWindow
+}
+
+// Query returns a page of stored reports matching the filter, newest first.
+func (s *MySQLDB) Query(filter ReportFilter) (ReportPage, error) {
+	query, args, err := buildReportQuery(filter, mysqlJSONExtract)
+	if err != nil {
+		return ReportPage{}, err
+	}
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return ReportPage{}, err
+	}
+	defer rows.Close()
+
+	return scanReportPage(rows, filter)
+}
+
+// Export calls fn with each stored report matching the filter, newest first,
+// streaming the reports from the database. The limit of the filter is ignored.
+func (s *MySQLDB) Export(filter ReportFilter, fn func(StoredReport) error) error {
+	query, args, err := buildReportSelect(filter, mysqlJSONExtract)
+	if err != nil {
+		return err
+	}
+	return exportReports(s.DB, query, args, fn)
+}
+
+// Get returns the report with the given ID, or ErrReportNotFound.
+func (s *MySQLDB) Get(id string) (StoredReport, error) {
+	return getReport(s.DB, id)
+}
+
+// Stats returns report counts grouped by the dimension of the query.
+func (s *MySQLDB) Stats(query StatsQuery) ([]StatsGroup, error) {
+	return queryStats(s.DB, query, func(unit string) string {
+		return fmt.Sprintf("DATE_FORMAT(created_at, '%s')", mysqlTimeBuckets[unit])
+	})
+}
+
+// Issues returns a page of issues matching the filter, newest first.
+func (s *MySQLDB) Issues(filter IssueFilter) (IssuePage, error) {
+	return queryIssues(s.DB, filter)
+}
+
+// GetIssue returns the issue with the given ID, or ErrIssueNotFound.
+func (s *MySQLDB) GetIssue(id string) (Issue, error) {
+	return getIssue(s.DB, id)
+}
+
+// UpdateIssue changes the triage state of the issue with the given ID, or returns ErrIssueNotFound.
+func (s *MySQLDB) UpdateIssue(id string, update IssueUpdate) (Issue, error) {
+	return updateIssue(s.DB, id, update)
+}
+
+// Purge deletes the reports and issues expired by the retention policy, then
+// optimizes the tables to reclaim the freed space.
+func (s *MySQLDB) Purge(policy RetentionPolicy) (PurgeResult, error) {
+	result, err := purge(s.DB, policy, time.Now())
+	if err != nil || result.Total() == 0 {
+		return result, err
+	}
+	_, err = s.DB.Exec("OPTIMIZE TABLE reports, issues")
+	return result, err
+}
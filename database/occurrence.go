@@ -0,0 +1,205 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// MaxUserAgentSamples is the number of distinct user agents kept per report.
+const MaxUserAgentSamples = 10
+
+// Occurrence is one or more sightings of a report, identified by its hash.
+type Occurrence struct {
+	ReportType string
+	Report     types.Report
+	Hash       string
+	// UserAgents are the distinct user agents that sent the report.
+	UserAgents []string
+	// Count is the number of times the report was received.
+	Count     int
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// NewOccurrence returns a single sighting of a report at the given time.
+func NewOccurrence(reportType string, report types.Report, userAgent, hash string, at time.Time) Occurrence {
+	return Occurrence{
+		ReportType: reportType,
+		Report:     report,
+		Hash:       hash,
+		UserAgents: []string{userAgent},
+		Count:      1,
+		FirstSeen:  at,
+		LastSeen:   at,
+	}
+}
+
+// Merge adds the sightings of other, which must have the same hash, to o.
+func (o *Occurrence) Merge(other Occurrence) {
+	o.Count += other.Count
+	if other.FirstSeen.Before(o.FirstSeen) {
+		o.FirstSeen = other.FirstSeen
+	}
+	if other.LastSeen.After(o.LastSeen) {
+		o.LastSeen = other.LastSeen
+	}
+	o.UserAgents = mergeUserAgents(o.UserAgents, other.UserAgents)
+}
+
+// mergeUserAgents adds the user agents in add to samples, keeping at most
+// MaxUserAgentSamples distinct values.
+func mergeUserAgents(samples, add []string) []string {
+	merged := append([]string{}, samples...)
+	for _, ua := range add {
+		if len(merged) >= MaxUserAgentSamples {
+			break
+		}
+		if !containsString(merged, ua) {
+			merged = append(merged, ua)
+		}
+	}
+	return merged
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// DedupKey returns the key that queued reports with the given hash received at
+// t are merged on. Keys are aligned to multiples of window since midnight UTC,
+// so reports merged on a key are less than window apart; the database then
+// counts them on the report of the hash last seen within window (see
+// recordOccurrence). A zero window returns the hash itself, deduplicating
+// forever.
+func DedupKey(hash string, t time.Time, window time.Duration) string {
+	if window <= 0 {
+		return hash
+	}
+	return fmt.Sprintf("%s/%d", hash, t.UTC().Truncate(window).Unix())
+}
+
+// upsertQueries are the dialect-specific statements used by recordOccurrence.
+type upsertQueries struct {
+	// report takes the id, report_type, data, user_agent, hash, dedup_key,
+	// occurrences, first_seen, last_seen, user_agents, directive, blocked_host,
+	// document_path, browser and issue_id values in that order.
+	report string
+	// issue is the upsert query of recordIssue.
+	issue string
+}
+
+// recordOccurrence inserts the report of an occurrence, or adds the occurrence to
+// the existing report with the same hash, and counts it towards its issue. With
+// a dedup window, the occurrence is added to the report of the hash last seen
+// at most window before its first sighting, so the window slides with each
+// duplicate; an occurrence seen later starts a new report.
+//
+// Counts are updated atomically by the upserts. The user agent sample is merged
+// before the upsert, so concurrent writers may drop a sample, and concurrent
+// writers starting a new report of the same hash in a window may each insert one.
+func recordOccurrence(db *sql.DB, o Occurrence, window time.Duration, queries upsertQueries) error {
+	data, err := o.Report.JSON()
+	if err != nil {
+		return err
+	}
+
+	count := o.Count
+	if count < 1 {
+		count = 1
+	}
+
+	ms := ulid.Timestamp(time.Now())
+	id, err := ulid.New(ms, rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	issueID, err := recordIssue(tx, o, count, queries.issue)
+	if err != nil {
+		return err
+	}
+
+	key := o.Hash
+	var existing []byte
+	if window > 0 {
+		key, existing, err = windowReport(tx, o, window)
+		if err != nil {
+			return err
+		}
+		if key == "" {
+			// Keys of the reports of a hash are unique by their id
+			key = o.Hash + "/" + id.String()
+		}
+	} else {
+		err = tx.QueryRow("SELECT user_agents FROM reports WHERE dedup_key = ?", key).Scan(&existing)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+	}
+
+	var samples []string
+	if len(existing) > 0 {
+		if err := json.Unmarshal(existing, &samples); err != nil {
+			return err
+		}
+	}
+	samples = mergeUserAgents(samples, o.UserAgents)
+
+	userAgents, err := json.Marshal(samples)
+	if err != nil {
+		return err
+	}
+
+	var userAgent string
+	if len(o.UserAgents) > 0 {
+		userAgent = o.UserAgents[0]
+	}
+
+	d := newDimensions(o.Report, userAgent)
+	_, err = tx.Exec(queries.report, id.String(), o.ReportType, string(data), userAgent, o.Hash, key, count, o.FirstSeen.UTC(), o.LastSeen.UTC(), string(userAgents),
+		d.Directive, d.BlockedHost, d.DocumentPath, d.Browser, issueID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// windowReport returns the dedup key and user agent sample of the report of
+// the occurrence's hash last seen at most window before its first sighting, or
+// an empty key if there is none.
+func windowReport(tx *sql.Tx, o Occurrence, window time.Duration) (string, []byte, error) {
+	var key string
+	var userAgents []byte
+	var lastSeen sql.NullTime
+	err := tx.QueryRow("SELECT dedup_key, user_agents, last_seen FROM reports WHERE hash = ? ORDER BY last_seen DESC LIMIT 1", o.Hash).
+		Scan(&key, &userAgents, &lastSeen)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	if o.FirstSeen.After(lastSeen.Time.Add(window)) {
+		return "", nil, nil
+	}
+	return key, userAgents, nil
+}
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	migrate_postgres "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/lib/pq"
+	"github.com/vinsonio/security-report-collector/config"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// pgUniqueViolation is the SQLSTATE of a unique constraint violation.
+const pgUniqueViolation = "23505"
+
+// PostgresDB is a PostgreSQL-backed database implementation.
+type PostgresDB struct {
+	DB *sql.DB
+	// dedupWindow is how long reports with the same hash are counted as one
+	// report (see DedupKey); zero deduplicates forever.
+	dedupWindow time.Duration
+}
+
+func init() {
+	RegisterDB("postgres", func(cfg *config.DB) (DB, error) {
+		return NewPostgresDB(cfg.Postgres, cfg.DedupWindow)
+	})
+}
+
+// NewPostgresDB creates a new PostgresDB. Sessions use UTC, so that stats time
+// buckets are in UTC like on SQLite.
+func NewPostgresDB(cfg config.Postgres, dedupWindow time.Duration) (Store, error) {
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:     "/" + cfg.Database,
+		RawQuery: url.Values{"sslmode": {cfg.SSLMode}, "timezone": {"UTC"}}.Encode(),
+	}
+
+	connector, err := pq.NewConnector(dsn.String())
+	if err != nil {
+		return nil, err
+	}
+
+	db := sql.OpenDB(rebindConnector{connector})
+	if err = db.Ping(); err != nil {
+		return nil, err
+	}
+
+	return &PostgresDB{DB: db, dedupWindow: dedupWindow}, nil
+}
+
+// Migrate runs the database migrations.
+func (s *PostgresDB) Migrate() error {
+	var driver database.Driver
+	driver, err := migrate_postgres.WithInstance(s.DB, &migrate_postgres.Config{})
+	if err != nil {
+		return err
+	}
+
+	return runMigrations(driver, "postgres", "postgres")
+}
+
+// pgUpsertReport inserts a report or adds to the occurrences of the report with the same dedup key.
+const pgUpsertReport = `INSERT INTO reports (id, report_type, data, user_agent, hash, dedup_key, occurrences, first_seen, last_seen, user_agents, directive, blocked_host, document_path, browser, issue_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (dedup_key) DO UPDATE SET
+	occurrences = reports.occurrences + excluded.occurrences,
+	first_seen = LEAST(reports.first_seen, excluded.first_seen),
+	last_seen = GREATEST(reports.last_seen, excluded.last_seen),
+	user_agents = excluded.user_agents,
+	issue_id = COALESCE(reports.issue_id, excluded.issue_id)`
+
+// pgUpsertIssue inserts an issue or adds to the occurrences of the issue with the same fingerprint.
+const pgUpsertIssue = `INSERT INTO issues (id, fingerprint, report_type, directive, blocked_origin, occurrences, first_seen, last_seen, status_changed_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (fingerprint) DO UPDATE SET
+	occurrences = issues.occurrences + excluded.occurrences,
+	first_seen = LEAST(issues.first_seen, excluded.first_seen),
+	last_seen = GREATEST(issues.last_seen, excluded.last_seen)`
+
+// pgTimeBuckets are the formats of the stats time buckets.
+var pgTimeBuckets = map[string]string{
+	GroupByMinute: "YYYY-MM-DD HH24:MI",
+	GroupByHour:   "YYYY-MM-DD HH24:00",
+	GroupByDay:    "YYYY-MM-DD",
+}
+
+// pgJSONExtract returns an SQL expression evaluating to the text value at a JSON path placeholder.
+func pgJSONExtract(path string) string {
+	return fmt.Sprintf("jsonb_path_query_first(data, CAST(%s AS jsonpath)) #>> '{}'", path)
+}
+
+// Save saves a report to the database, or counts another occurrence if a report
+// with the same hash exists within the dedup window.
+func (s *PostgresDB) Save(reportType string, report types.Report, userAgent, hash string) error {
+	return s.Record(NewOccurrence(reportType, report, userAgent, hash, time.Now()))
+}
+
+// Record saves the report of an occurrence, or adds the occurrence to the report
+// with the same hash within the dedup window. Duplicates are counted by the
+// upsert; a unique violation that gets past it is returned as ErrDuplicateReport.
+func (s *PostgresDB) Record(occurrence Occurrence) error {
+	err := recordOccurrence(s.DB, occurrence, s.dedupWindow, upsertQueries{report: pgUpsertReport, issue: pgUpsertIssue})
+	if isUniqueViolation(err) {
+		return ErrDuplicateReport
+	}
+	return err
+}
+
+// DedupWindow returns how long reports with the same hash are counted as one report.
+func (s *PostgresDB) DedupWindow() time.Duration {
+	return s.dedupWindow
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pgUniqueViolation
+}
+
+// Query returns a page of stored reports matching the filter, newest first.
+func (s *PostgresDB) Query(filter ReportFilter) (ReportPage, error) {
+	query, args, err := buildReportQuery(filter, pgJSONExtract)
+	if err != nil {
+		return ReportPage{}, err
+	}
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return ReportPage{}, err
+	}
+	defer rows.Close()
+
+	return scanReportPage(rows, filter)
+}
+
+// Export calls fn with each stored report matching the filter, newest first,
+// streaming the reports from the database. The limit of the filter is ignored.
+func (s *PostgresDB) Export(filter ReportFilter, fn func(StoredReport) error) error {
+	query, args, err := buildReportSelect(filter, pgJSONExtract)
+	if err != nil {
+		return err
+	}
+	return exportReports(s.DB, query, args, fn)
+}
+
+// Get returns the report with the given ID, or ErrReportNotFound.
+func (s *PostgresDB) Get(id string) (StoredReport, error) {
+	return getReport(s.DB, id)
+}
+
+// Stats returns report counts grouped by the dimension of the query.
+func (s *PostgresDB) Stats(query StatsQuery) ([]StatsGroup, error) {
+	return queryStats(s.DB, query, func(unit string) string {
+		return fmt.Sprintf("to_char(created_at, '%s')", pgTimeBuckets[unit])
+	})
+}
+
+// Issues returns a page of issues matching the filter, newest first.
+func (s *PostgresDB) Issues(filter IssueFilter) (IssuePage, error) {
+	return queryIssues(s.DB, filter)
+}
+
+// GetIssue returns the issue with the given ID, or ErrIssueNotFound.
+func (s *PostgresDB) GetIssue(id string) (Issue, error) {
+	return getIssue(s.DB, id)
+}
+
+// UpdateIssue changes the triage state of the issue with the given ID, or returns ErrIssueNotFound.
+func (s *PostgresDB) UpdateIssue(id string, update IssueUpdate) (Issue, error) {
+	return updateIssue(s.DB, id, update)
+}
+
+// Purge deletes the reports and issues expired by the retention policy, then
+// vacuums the tables so that the freed space can be reused.
+func (s *PostgresDB) Purge(policy RetentionPolicy) (PurgeResult, error) {
+	result, err := purge(s.DB, policy, time.Now())
+	if err != nil || result.Total() == 0 {
+		return result, err
+	}
+	_, err = s.DB.Exec("VACUUM ANALYZE reports, issues")
+	return result, err
+}
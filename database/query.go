@@ -0,0 +1,297 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// DefaultQueryLimit is the number of reports returned when a filter has no limit.
+const DefaultQueryLimit = 50
+
+// MaxQueryLimit is the maximum number of reports returned by a single query.
+const MaxQueryLimit = 500
+
+// fieldSegmentPattern restricts the segments of a data field path.
+var fieldSegmentPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ReportFilter describes which stored reports to return. Zero values are ignored.
+type ReportFilter struct {
+	// Type matches the stored report type, e.g. "csp".
+	Type string
+	// Hash matches the report hash.
+	Hash string
+	// Directive matches the violated directive or feature (see types.Summary).
+	Directive string
+	// Issue matches the ID of the issue the report belongs to.
+	Issue string
+	// From and To bound the time the report was stored; From is inclusive, To exclusive.
+	From time.Time
+	To   time.Time
+	// UserAgent matches reports whose user agent contains the given substring.
+	UserAgent string
+	// Fields matches values inside the report data, keyed by dot-separated path
+	// (e.g. "body.effectiveDirective").
+	Fields map[string]string
+	// Cursor returns reports stored before the report with this ID.
+	Cursor string
+	// Limit is the maximum number of reports to return.
+	Limit int
+}
+
+// StoredReport is a report as stored in the database.
+type StoredReport struct {
+	ID         string          `json:"id"`
+	ReportType string          `json:"report_type"`
+	Data       json.RawMessage `json:"data,omitempty"`
+	UserAgent  string          `json:"user_agent"`
+	Hash       string          `json:"hash"`
+	CreatedAt  time.Time       `json:"created_at"`
+	// Occurrences is the number of times the report was received.
+	Occurrences int       `json:"occurrences"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+	// UserAgents is a sample of the distinct user agents that sent the report.
+	UserAgents []string `json:"user_agents,omitempty"`
+	// IssueID is the issue the report belongs to, empty for reports not seen
+	// since issues were introduced.
+	IssueID string `json:"issue_id,omitempty"`
+}
+
+// ReportPage is a page of stored reports, newest first.
+type ReportPage struct {
+	Reports []StoredReport `json:"reports"`
+	// NextCursor is the cursor for the next page, empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// reportColumns are the columns selected into a StoredReport.
+const reportColumns = "id, report_type, data, user_agent, hash, created_at, occurrences, first_seen, last_seen, user_agents, issue_id"
+
+// Validate checks the filter for malformed values.
+func (f ReportFilter) Validate() error {
+	if f.Limit < 0 || f.Limit > MaxQueryLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxQueryLimit)
+	}
+	if f.Cursor != "" {
+		if _, err := ulid.ParseStrict(f.Cursor); err != nil {
+			return fmt.Errorf("invalid cursor: %w", err)
+		}
+	}
+	for field := range f.Fields {
+		if _, err := jsonPath(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonPath converts a dot-separated field path into a quoted JSON path
+// understood by both SQLite and MySQL, e.g. $."body"."blockedURL".
+func jsonPath(field string) (string, error) {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, segment := range strings.Split(field, ".") {
+		if !fieldSegmentPattern.MatchString(segment) {
+			return "", fmt.Errorf("invalid field path: %s", field)
+		}
+		b.WriteString(`."`)
+		b.WriteString(segment)
+		b.WriteString(`"`)
+	}
+	return b.String(), nil
+}
+
+// timeBound returns the smallest report ID that can be generated at t. Report IDs
+// are ULIDs, so comparing against it selects reports stored before or after t.
+func timeBound(t time.Time) string {
+	var id ulid.ULID
+	_ = id.SetTime(ulid.Timestamp(t))
+	return id.String()
+}
+
+// buildReportQuery builds the SELECT statement for a page of reports matching a
+// filter. jsonExtract returns an SQL expression evaluating to the text value at
+// a JSON path placeholder.
+func buildReportQuery(f ReportFilter, jsonExtract func(placeholder string) string) (string, []interface{}, error) {
+	query, args, err := buildReportSelect(f, jsonExtract)
+	if err != nil {
+		return "", nil, err
+	}
+	// Fetch one extra row to find out whether there is a next page.
+	query += " LIMIT ?"
+	args = append(args, f.limit()+1)
+	return query, args, nil
+}
+
+// buildReportSelect builds the SELECT statement for all reports matching a
+// filter, newest first, ignoring its limit.
+func buildReportSelect(f ReportFilter, jsonExtract func(placeholder string) string) (string, []interface{}, error) {
+	if err := f.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	var conditions []string
+	var args []interface{}
+
+	if f.Type != "" {
+		conditions = append(conditions, "report_type = ?")
+		args = append(args, f.Type)
+	}
+	if f.Hash != "" {
+		conditions = append(conditions, "hash = ?")
+		args = append(args, f.Hash)
+	}
+	if f.Directive != "" {
+		conditions = append(conditions, "directive = ?")
+		args = append(args, f.Directive)
+	}
+	if f.Issue != "" {
+		conditions = append(conditions, "issue_id = ?")
+		args = append(args, f.Issue)
+	}
+	if !f.From.IsZero() {
+		conditions = append(conditions, "id >= ?")
+		args = append(args, timeBound(f.From))
+	}
+	if !f.To.IsZero() {
+		conditions = append(conditions, "id < ?")
+		args = append(args, timeBound(f.To))
+	}
+	if f.UserAgent != "" {
+		// LIKE is case-sensitive on PostgreSQL
+		conditions = append(conditions, "LOWER(user_agent) LIKE LOWER(?) ESCAPE '!'")
+		args = append(args, "%"+escapeLike(f.UserAgent)+"%")
+	}
+	if f.Cursor != "" {
+		conditions = append(conditions, "id < ?")
+		args = append(args, f.Cursor)
+	}
+	for _, field := range sortedKeys(f.Fields) {
+		path, err := jsonPath(field)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, jsonExtract("?")+" = ?")
+		args = append(args, path, f.Fields[field])
+	}
+
+	query := "SELECT " + reportColumns + " FROM reports"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC"
+
+	return query, args, nil
+}
+
+// limit returns the page size of the filter.
+func (f ReportFilter) limit() int {
+	if f.Limit == 0 {
+		return DefaultQueryLimit
+	}
+	return f.Limit
+}
+
+// sortedKeys returns the keys of m in a stable order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escapeLike escapes the LIKE wildcards in s using '!' as the escape character.
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+// scanReportPage reads the rows returned by a report query into a page.
+func scanReportPage(rows *sql.Rows, f ReportFilter) (ReportPage, error) {
+	reports, err := scanReports(rows)
+	if err != nil {
+		return ReportPage{}, err
+	}
+
+	page := ReportPage{Reports: reports}
+	if limit := f.limit(); len(reports) > limit {
+		page.Reports = reports[:limit]
+		page.NextCursor = page.Reports[limit-1].ID
+	}
+	return page, nil
+}
+
+// scanReports reads all rows returned by a report query.
+func scanReports(rows *sql.Rows) ([]StoredReport, error) {
+	reports := []StoredReport{}
+	for rows.Next() {
+		r, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
+
+// scanReport reads a single row selected with reportColumns.
+func scanReport(row interface {
+	Scan(dest ...interface{}) error
+}) (StoredReport, error) {
+	var r StoredReport
+	var data, userAgents []byte
+	var firstSeen, lastSeen sql.NullTime
+	var issueID sql.NullString
+	if err := row.Scan(&r.ID, &r.ReportType, &data, &r.UserAgent, &r.Hash, &r.CreatedAt, &r.Occurrences, &firstSeen, &lastSeen, &userAgents, &issueID); err != nil {
+		return StoredReport{}, err
+	}
+	r.IssueID = issueID.String
+	r.Data = json.RawMessage(data)
+	r.FirstSeen = firstSeen.Time
+	r.LastSeen = lastSeen.Time
+	if len(userAgents) > 0 {
+		if err := json.Unmarshal(userAgents, &r.UserAgents); err != nil {
+			return StoredReport{}, err
+		}
+	}
+	return r, nil
+}
+
+// exportReports calls fn with each report selected by query, reading the rows
+// as fn consumes them instead of loading all reports into memory.
+func exportReports(db *sql.DB, query string, args []interface{}, fn func(StoredReport) error) error {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r, err := scanReport(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// getReport returns the report with the given ID.
+func getReport(db *sql.DB, id string) (StoredReport, error) {
+	r, err := scanReport(db.QueryRow("SELECT "+reportColumns+" FROM reports WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return StoredReport{}, ErrReportNotFound
+	}
+	return r, err
+}
@@ -0,0 +1,32 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebind(t *testing.T) {
+	tests := map[string]string{
+		"SELECT 1":                                    "SELECT 1",
+		"SELECT id FROM reports WHERE id = ?":         "SELECT id FROM reports WHERE id = $1",
+		"UPDATE t SET a = ?, b = ? WHERE c IN (?, ?)": "UPDATE t SET a = $1, b = $2 WHERE c IN ($3, $4)",
+		"SELECT '?' AS q, \"a?\" FROM t WHERE x = ?":  "SELECT '?' AS q, \"a?\" FROM t WHERE x = $1",
+		"SELECT 'it''s ?' WHERE y LIKE ? ESCAPE '!'":  "SELECT 'it''s ?' WHERE y LIKE $1 ESCAPE '!'",
+		"SELECT data #>> '{}' FROM t WHERE id = ?":    "SELECT data #>> '{}' FROM t WHERE id = $1",
+	}
+	for query, want := range tests {
+		assert.Equal(t, want, rebind(query), query)
+	}
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	assert.True(t, isUniqueViolation(&pq.Error{Code: pgUniqueViolation}))
+	assert.True(t, isUniqueViolation(fmt.Errorf("insert: %w", &pq.Error{Code: pgUniqueViolation})))
+	assert.False(t, isUniqueViolation(&pq.Error{Code: "23503"}))
+	assert.False(t, isUniqueViolation(errors.New("duplicate")))
+	assert.False(t, isUniqueViolation(nil))
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/vinsonio/security-report-collector/config"
+)
+
+// DBBuilder creates a database backend from the database configuration.
+type DBBuilder func(cfg *config.DB) (DB, error)
+
+var (
+	dbBuildersMutex sync.RWMutex
+	dbBuilders      = make(map[string]DBBuilder)
+)
+
+// RegisterDB registers a database backend under the DB_CONNECTION name it is
+// selected by. Backends are usually registered from an init function; a backend
+// registered under the name of another replaces it, including the built-in
+// sqlite, mysql and postgres backends.
+func RegisterDB(name string, builder DBBuilder) {
+	if builder == nil {
+		panic(fmt.Sprintf("database: nil builder for %s", name))
+	}
+
+	dbBuildersMutex.Lock()
+	defer dbBuildersMutex.Unlock()
+	dbBuilders[name] = builder
+}
+
+// GetDBBuilder returns the builder of the database backend registered under name.
+func GetDBBuilder(name string) (DBBuilder, error) {
+	dbBuildersMutex.RLock()
+	defer dbBuildersMutex.RUnlock()
+
+	builder, ok := dbBuilders[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database connection: %s", name)
+	}
+	return builder, nil
+}
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultPurgeBatchSize is the number of rows deleted at a time when the
+// retention policy does not set a batch size.
+const DefaultPurgeBatchSize = 500
+
+// RetentionPolicy describes which stored reports are purged. Zero values keep reports.
+type RetentionPolicy struct {
+	// MaxAge purges reports last seen longer ago than this.
+	MaxAge time.Duration
+	// MaxAgeByType overrides MaxAge for report types; zero keeps reports of the type forever.
+	MaxAgeByType map[string]time.Duration
+	// MaxReports purges the least recently seen reports beyond this number.
+	MaxReports int
+	// BatchSize is the number of rows deleted per statement, so that a purge
+	// does not lock the database for long.
+	BatchSize int
+}
+
+// PurgeResult counts the rows deleted by a purge.
+type PurgeResult struct {
+	// Expired is the number of reports older than their maximum age.
+	Expired int64 `json:"expired"`
+	// Excess is the number of reports beyond the maximum number of reports.
+	Excess int64 `json:"excess"`
+	// Issues is the number of issues older than their maximum age. Acknowledged
+	// and ignored issues are kept, so that their triage state survives.
+	Issues int64 `json:"issues"`
+}
+
+// Total returns the total number of deleted rows.
+func (r PurgeResult) Total() int64 {
+	return r.Expired + r.Excess + r.Issues
+}
+
+// batchSize returns the number of rows deleted per statement.
+func (p RetentionPolicy) batchSize() int {
+	if p.BatchSize <= 0 {
+		return DefaultPurgeBatchSize
+	}
+	return p.BatchSize
+}
+
+// ageRule purges the rows matching a condition on the report type last seen before a cutoff.
+type ageRule struct {
+	where string
+	args  []interface{}
+}
+
+// ageRules returns the conditions on report_type and last_seen selecting the
+// rows expired at now, one per report type with its own maximum age and one
+// for the other types.
+func (p RetentionPolicy) ageRules(now time.Time) []ageRule {
+	names := make([]string, 0, len(p.MaxAgeByType))
+	for name := range p.MaxAgeByType {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var rules []ageRule
+	for _, name := range names {
+		if maxAge := p.MaxAgeByType[name]; maxAge > 0 {
+			rules = append(rules, ageRule{
+				where: "report_type = ? AND last_seen < ?",
+				args:  []interface{}{name, now.Add(-maxAge).UTC()},
+			})
+		}
+	}
+
+	if p.MaxAge > 0 {
+		rule := ageRule{where: "last_seen < ?", args: []interface{}{now.Add(-p.MaxAge).UTC()}}
+		if len(names) > 0 {
+			rule.where = "report_type NOT IN (" + placeholders(len(names)) + ") AND " + rule.where
+			args := make([]interface{}, 0, len(names)+1)
+			for _, name := range names {
+				args = append(args, name)
+			}
+			rule.args = append(args, rule.args...)
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// purge deletes the reports and issues expired by the policy at now, then the
+// least recently seen reports beyond its maximum number of reports.
+func purge(db *sql.DB, p RetentionPolicy, now time.Time) (PurgeResult, error) {
+	var result PurgeResult
+	for _, rule := range p.ageRules(now) {
+		n, err := deleteBatches(db, "reports", rule.where, "", rule.args, p.batchSize(), 0)
+		result.Expired += n
+		if err != nil {
+			return result, err
+		}
+
+		n, err = deleteBatches(db, "issues", rule.where+" AND status NOT IN (?, ?)", "",
+			append(rule.args, IssueAcknowledged, IssueIgnored), p.batchSize(), 0)
+		result.Issues += n
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if p.MaxReports > 0 {
+		var count int64
+		if err := db.QueryRow("SELECT COUNT(*) FROM reports").Scan(&count); err != nil {
+			return result, err
+		}
+		if excess := count - int64(p.MaxReports); excess > 0 {
+			n, err := deleteBatches(db, "reports", "", "last_seen, id", nil, p.batchSize(), excess)
+			result.Excess += n
+			if err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// deleteBatches deletes the rows of table matching where, batchSize rows at a
+// time in orderBy order, until none are left or max rows were deleted. A
+// max of zero deletes all matching rows. It returns the number of deleted rows.
+func deleteBatches(db *sql.DB, table, where, orderBy string, args []interface{}, batchSize int, max int64) (int64, error) {
+	query := "SELECT id FROM " + table
+	if where != "" {
+		query += " WHERE " + where
+	}
+	if orderBy != "" {
+		query += " ORDER BY " + orderBy
+	}
+	query += " LIMIT ?"
+
+	var deleted int64
+	for max == 0 || deleted < max {
+		limit := int64(batchSize)
+		if max > 0 && max-deleted < limit {
+			limit = max - deleted
+		}
+
+		ids, err := selectIDs(db, query, append(args[:len(args):len(args)], limit)...)
+		if err != nil || len(ids) == 0 {
+			return deleted, err
+		}
+
+		res, err := db.Exec("DELETE FROM "+table+" WHERE id IN ("+placeholders(len(ids))+")", ids...)
+		if err != nil {
+			return deleted, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+
+		if int64(len(ids)) < limit {
+			break
+		}
+	}
+	return deleted, nil
+}
+
+// selectIDs returns the IDs selected by query.
+func selectIDs(db *sql.DB, query string, args ...interface{}) ([]interface{}, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []interface{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// placeholders returns n comma-separated query placeholders.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
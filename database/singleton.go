@@ -3,7 +3,7 @@ package database
 import (
 	"sync"
 
-	"github.com/vinsonio/security-report-collector/internal/config"
+	"github.com/vinsonio/security-report-collector/config"
 )
 
 var (
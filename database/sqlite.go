@@ -0,0 +1,170 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/vinsonio/security-report-collector/config"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// SQLiteDB is a db that uses SQLite.
+type SQLiteDB struct {
+	DB *sql.DB
+	// dedupWindow is how long reports with the same hash are counted as one
+	// report (see DedupKey); zero deduplicates forever.
+	dedupWindow time.Duration
+}
+
+func init() {
+	RegisterDB("sqlite", func(cfg *config.DB) (DB, error) {
+		return NewSQLiteDB(cfg.SQLite, cfg.DedupWindow)
+	})
+}
+
+// sqliteOptions enables write-ahead logging, so that reads such as a long
+// export do not block writes, and makes a locked database wait for the lock
+// instead of failing.
+const sqliteOptions = "_journal_mode=WAL&_busy_timeout=5000"
+
+// NewSQLiteDB creates a new SQLiteDB.
+func NewSQLiteDB(cfg config.SQLite, dedupWindow time.Duration) (Store, error) {
+	dsn := cfg.Database + "?" + sqliteOptions
+	if strings.Contains(cfg.Database, "?") {
+		dsn = cfg.Database + "&" + sqliteOptions
+	}
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLiteDB{DB: db, dedupWindow: dedupWindow}, nil
+}
+
+// Migrate runs the database migrations.
+func (s *SQLiteDB) Migrate() error {
+	var driver database.Driver
+	driver, err := sqlite3.WithInstance(s.DB, &sqlite3.Config{})
+	if err != nil {
+		return err
+	}
+
+	return runMigrations(driver, "sqlite", "sqlite3")
+}
+
+// sqliteUpsertReport inserts a report or adds to the occurrences of the report with the same dedup key.
+const sqliteUpsertReport = `INSERT INTO reports (id, report_type, data, user_agent, hash, dedup_key, occurrences, first_seen, last_seen, user_agents, directive, blocked_host, document_path, browser, issue_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(dedup_key) DO UPDATE SET
+	occurrences = occurrences + excluded.occurrences,
+	first_seen = MIN(COALESCE(first_seen, excluded.first_seen), excluded.first_seen),
+	last_seen = MAX(COALESCE(last_seen, excluded.last_seen), excluded.last_seen),
+	user_agents = excluded.user_agents,
+	issue_id = COALESCE(issue_id, excluded.issue_id)`
+
+// sqliteUpsertIssue inserts an issue or adds to the occurrences of the issue with the same fingerprint.
+const sqliteUpsertIssue = `INSERT INTO issues (id, fingerprint, report_type, directive, blocked_origin, occurrences, first_seen, last_seen, status_changed_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(fingerprint) DO UPDATE SET
+	occurrences = occurrences + excluded.occurrences,
+	first_seen = MIN(first_seen, excluded.first_seen),
+	last_seen = MAX(last_seen, excluded.last_seen)`
+
+// sqliteTimeBuckets are the formats of the stats time buckets.
+var sqliteTimeBuckets = map[string]string{
+	GroupByMinute: "%Y-%m-%d %H:%M",
+	GroupByHour:   "%Y-%m-%d %H:00",
+	GroupByDay:    "%Y-%m-%d",
+}
+
+// sqliteJSONExtract returns an SQL expression evaluating to the text value at a JSON path placeholder.
+func sqliteJSONExtract(path string) string {
+	return fmt.Sprintf("CAST(json_extract(data, %s) AS TEXT)", path)
+}
+
+// Save saves a report to the database, or counts another occurrence if a report
+// with the same hash exists within the dedup window.
+func (s *SQLiteDB) Save(reportType string, report types.Report, userAgent, hash string) error {
+	return s.Record(NewOccurrence(reportType, report, userAgent, hash, time.Now()))
+}
+
+// Record saves the report of an occurrence, or adds the occurrence to the report
+// with the same hash within the dedup window.
+func (s *SQLiteDB) Record(occurrence Occurrence) error {
+	return recordOccurrence(s.DB, occurrence, s.dedupWindow, upsertQueries{report: sqliteUpsertReport, issue: sqliteUpsertIssue})
+}
+
+// DedupWindow returns how long reports with the same hash are counted as one report.
+func (s *SQLiteDB) DedupWindow() time.Duration {
+	return s.dedupWindow
+}
+
+// Query returns a page of stored reports matching the filter, newest first.
+func (s *SQLiteDB) Query(filter ReportFilter) (ReportPage, error) {
+	query, args, err := buildReportQuery(filter, sqliteJSONExtract)
+	if err != nil {
+		return ReportPage{}, err
+	}
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return ReportPage{}, err
+	}
+	defer rows.Close()
+
+	return scanReportPage(rows, filter)
+}
+
+// Export calls fn with each stored report matching the filter, newest first,
+// streaming the reports from the database. The limit of the filter is ignored.
+func (s *SQLiteDB) Export(filter ReportFilter, fn func(StoredReport) error) error {
+	query, args, err := buildReportSelect(filter, sqliteJSONExtract)
+	if err != nil {
+		return err
+	}
+	return exportReports(s.DB, query, args, fn)
+}
+
+// Get returns the report with the given ID, or ErrReportNotFound.
+func (s *SQLiteDB) Get(id string) (StoredReport, error) {
+	return getReport(s.DB, id)
+}
+
+// Stats returns report counts grouped by the dimension of the query.
+func (s *SQLiteDB) Stats(query StatsQuery) ([]StatsGroup, error) {
+	return queryStats(s.DB, query, func(unit string) string {
+		return fmt.Sprintf("strftime('%s', created_at)", sqliteTimeBuckets[unit])
+	})
+}
+
+// Issues returns a page of issues matching the filter, newest first.
+func (s *SQLiteDB) Issues(filter IssueFilter) (IssuePage, error) {
+	return queryIssues(s.DB, filter)
+}
+
+// GetIssue returns the issue with the given ID, or ErrIssueNotFound.
+func (s *SQLiteDB) GetIssue(id string) (Issue, error) {
+	return getIssue(s.DB, id)
+}
+
+// UpdateIssue changes the triage state of the issue with the given ID, or returns ErrIssueNotFound.
+func (s *SQLiteDB) UpdateIssue(id string, update IssueUpdate) (Issue, error) {
+	return updateIssue(s.DB, id, update)
+}
+
+// Purge deletes the reports and issues expired by the retention policy, then
+// vacuums the database file to return the freed space to the file system.
+func (s *SQLiteDB) Purge(policy RetentionPolicy) (PurgeResult, error) {
+	result, err := purge(s.DB, policy, time.Now())
+	if err != nil || result.Total() == 0 {
+		return result, err
+	}
+	_, err = s.DB.Exec("VACUUM")
+	return result, err
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultStatsLimit is the number of groups returned when a stats query has no limit.
+const DefaultStatsLimit = 10
+
+// MaxStatsLimit is the maximum number of groups returned by a stats query.
+const MaxStatsLimit = 1000
+
+// Stats dimensions that reports can be grouped by.
+const (
+	GroupByType         = "type"
+	GroupByDirective    = "directive"
+	GroupByBlockedHost  = "blocked_host"
+	GroupByDocumentPath = "document_path"
+	GroupByBrowser      = "browser"
+	GroupByMinute       = "minute"
+	GroupByHour         = "hour"
+	GroupByDay          = "day"
+)
+
+// groupByColumns maps the non-time dimensions to their columns.
+var groupByColumns = map[string]string{
+	GroupByType:         "report_type",
+	GroupByDirective:    "directive",
+	GroupByBlockedHost:  "blocked_host",
+	GroupByDocumentPath: "document_path",
+	GroupByBrowser:      "browser",
+}
+
+// StatsQuery describes how to aggregate stored reports.
+type StatsQuery struct {
+	// GroupBy is the dimension to group by, one of the GroupBy constants.
+	GroupBy string
+	// Type restricts the statistics to a stored report type.
+	Type string
+	// From and To bound the time the report was first stored; From is inclusive, To exclusive.
+	From time.Time
+	To   time.Time
+	// Limit is the maximum number of groups to return.
+	Limit int
+}
+
+// StatsGroup is the aggregate of the reports sharing a dimension value.
+type StatsGroup struct {
+	// Value is the dimension value; empty for reports without one.
+	Value string `json:"value"`
+	// Count is the number of report occurrences. It is omitted for time
+	// buckets, as the later occurrences of a deduplicated report are counted
+	// without a time.
+	Count int64 `json:"count,omitempty"`
+	// Reports is the number of distinct stored reports; for time buckets, the
+	// number of new reports first stored in the bucket.
+	Reports int64 `json:"reports"`
+}
+
+// IsTimeBucket reports whether the query groups by time.
+func (q StatsQuery) IsTimeBucket() bool {
+	return q.GroupBy == GroupByMinute || q.GroupBy == GroupByHour || q.GroupBy == GroupByDay
+}
+
+// Validate checks the query for unsupported values.
+func (q StatsQuery) Validate() error {
+	if _, ok := groupByColumns[q.GroupBy]; !ok && !q.IsTimeBucket() {
+		return fmt.Errorf("unsupported group_by: %q", q.GroupBy)
+	}
+	if q.Limit < 0 || q.Limit > MaxStatsLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxStatsLimit)
+	}
+	return nil
+}
+
+// buildStatsQuery builds the aggregation statement for a stats query. timeBucket
+// returns an SQL expression truncating created_at to the given unit.
+//
+// Groups are ordered by count, largest first. Time buckets are ordered newest
+// first instead, so that the limit keeps the most recent buckets. Time buckets
+// only count the new reports stored in them: summing their occurrences would
+// put every occurrence of a report in the bucket it was first seen in.
+func buildStatsQuery(q StatsQuery, timeBucket func(unit string) string) (string, []interface{}, error) {
+	if err := q.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	var dimension, count string
+	if q.IsTimeBucket() {
+		dimension, count = timeBucket(q.GroupBy), "0"
+	} else {
+		dimension, count = groupByColumns[q.GroupBy], "SUM(occurrences)"
+	}
+
+	var conditions []string
+	var args []interface{}
+	if q.Type != "" {
+		conditions = append(conditions, "report_type = ?")
+		args = append(args, q.Type)
+	}
+	if !q.From.IsZero() {
+		conditions = append(conditions, "id >= ?")
+		args = append(args, timeBound(q.From))
+	}
+	if !q.To.IsZero() {
+		conditions = append(conditions, "id < ?")
+		args = append(args, timeBound(q.To))
+	}
+
+	query := "SELECT COALESCE(" + dimension + ", '') AS value, " + count + " AS count, COUNT(*) AS reports FROM reports"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " GROUP BY value"
+	if q.IsTimeBucket() {
+		query += " ORDER BY value DESC"
+	} else {
+		query += " ORDER BY count DESC, value"
+	}
+	query += " LIMIT ?"
+
+	limit := q.Limit
+	if limit == 0 {
+		limit = DefaultStatsLimit
+	}
+	args = append(args, limit)
+
+	return query, args, nil
+}
+
+// queryStats runs a stats query built by buildStatsQuery.
+func queryStats(db *sql.DB, q StatsQuery, timeBucket func(unit string) string) ([]StatsGroup, error) {
+	query, args, err := buildStatsQuery(q, timeBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []StatsGroup{}
+	for rows.Next() {
+		var g StatsGroup
+		if err := rows.Scan(&g.Value, &g.Count, &g.Reports); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Time buckets are selected newest first to keep the most recent ones, but returned in chronological order
+	if q.IsTimeBucket() {
+		for i, j := 0, len(groups)-1; i < j; i, j = i+1, j-1 {
+			groups[i], groups[j] = groups[j], groups[i]
+		}
+	}
+	return groups, nil
+}
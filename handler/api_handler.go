@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vinsonio/security-report-collector/database"
+	"github.com/vinsonio/security-report-collector/service"
+)
+
+// dataFieldPrefix prefixes query parameters that filter on fields of the report data.
+const dataFieldPrefix = "data."
+
+// ListReports returns a new http.Handler listing stored reports. Reports are
+// filtered by the type, hash, directive, issue, from, to, user_agent and
+// data.<path> query parameters and paginated with the cursor and limit query parameters.
+func ListReports(reportService *service.ReportService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseReportFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		page, err := reportService.ListReports(filter)
+		if err != nil {
+			log.Printf("failed to list reports: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, page)
+	}
+}
+
+// reportDetailResponse is the response body of GetReport.
+type reportDetailResponse struct {
+	database.StoredReport
+	// Data is the decoded report, or the stored JSON if it cannot be decoded.
+	Data    interface{}             `json:"data"`
+	Related []database.StoredReport `json:"related"`
+}
+
+// GetReport returns a new http.Handler returning a single stored report with
+// its decoded data and the other reports sharing its hash.
+func GetReport(reportService *service.ReportService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		detail, err := reportService.GetReport(chi.URLParam(r, "id"))
+		if errors.Is(err, database.ErrReportNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("failed to get report: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		resp := reportDetailResponse{
+			StoredReport: detail.StoredReport,
+			Data:         detail.StoredReport.Data,
+			Related:      detail.Related,
+		}
+		if detail.Report != nil {
+			resp.Data = detail.Report
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// statsResponse is the response body of Stats.
+type statsResponse struct {
+	GroupBy string                `json:"group_by"`
+	Groups  []database.StatsGroup `json:"groups"`
+}
+
+// Stats returns a new http.Handler aggregating stored reports. Reports are
+// grouped by the group_by query parameter and filtered by the type, from and
+// to query parameters; limit caps the number of groups.
+func Stats(reportService *service.ReportService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query, err := parseStatsQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		groups, err := reportService.Stats(query)
+		if err != nil {
+			log.Printf("failed to compute stats: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, statsResponse{GroupBy: query.GroupBy, Groups: groups})
+	}
+}
+
+// parseStatsQuery builds a stats query from query parameters.
+func parseStatsQuery(values url.Values) (database.StatsQuery, error) {
+	query := database.StatsQuery{
+		GroupBy: values.Get("group_by"),
+		Type:    values.Get("type"),
+	}
+
+	var err error
+	if query.From, err = parseTimeParam(values, "from"); err != nil {
+		return query, err
+	}
+	if query.To, err = parseTimeParam(values, "to"); err != nil {
+		return query, err
+	}
+	if query.Limit, err = parseLimitParam(values); err != nil {
+		return query, err
+	}
+
+	return query, query.Validate()
+}
+
+// parseReportFilter builds a report filter from query parameters.
+func parseReportFilter(query url.Values) (database.ReportFilter, error) {
+	filter := database.ReportFilter{
+		Type:      query.Get("type"),
+		Hash:      query.Get("hash"),
+		Directive: query.Get("directive"),
+		Issue:     query.Get("issue"),
+		UserAgent: query.Get("user_agent"),
+		Cursor:    query.Get("cursor"),
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(query, "from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseTimeParam(query, "to"); err != nil {
+		return filter, err
+	}
+
+	if filter.Limit, err = parseLimitParam(query); err != nil {
+		return filter, err
+	}
+
+	for key, values := range query {
+		if !strings.HasPrefix(key, dataFieldPrefix) {
+			continue
+		}
+		if filter.Fields == nil {
+			filter.Fields = make(map[string]string)
+		}
+		filter.Fields[strings.TrimPrefix(key, dataFieldPrefix)] = values[0]
+	}
+
+	return filter, filter.Validate()
+}
+
+// parseTimeParam parses an RFC 3339 query parameter; a missing parameter yields the zero time.
+func parseTimeParam(query url.Values, name string) (time.Time, error) {
+	v := query.Get(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: expected an RFC 3339 timestamp", name)
+	}
+	return t, nil
+}
+
+// parseLimitParam parses the limit query parameter; a missing parameter yields 0.
+func parseLimitParam(query url.Values) (int, error) {
+	v := query.Get("limit")
+	if v == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit < 1 {
+		return 0, fmt.Errorf("invalid limit: %s", v)
+	}
+	return limit, nil
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to write response: %v", err)
+	}
+}
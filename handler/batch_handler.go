@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/vinsonio/security-report-collector/database"
+	"github.com/vinsonio/security-report-collector/service"
+)
+
+// reportingAPIReport is a single element of a Reporting API batch. Only the
+// fields needed for routing are decoded; the element itself is passed on to
+// the report handler untouched.
+type reportingAPIReport struct {
+	Type      string `json:"type"`
+	UserAgent string `json:"user_agent"`
+}
+
+// CreateReportBatch returns a new http.Handler for Reporting API batches
+// (application/reports+json). Each element is routed to a report handler by its
+// "type" field, using the reporting types declared in the registry of the
+// service, and saved individually; failing elements are logged and skipped.
+func CreateReportBatch(reportService *service.ReportService, handlers map[string]ReportHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var items []json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		saved := 0
+		for i, item := range items {
+			var meta reportingAPIReport
+			if err := json.Unmarshal(item, &meta); err != nil {
+				log.Printf("Skipping batch report %d: %v", i, err)
+				continue
+			}
+
+			rt, ok := reportService.Registry().LookupReportingType(meta.Type)
+			if !ok {
+				log.Printf("Skipping batch report %d: unsupported type %q", i, meta.Type)
+				continue
+			}
+			reportType := rt.Name
+			handler, ok := handlers[reportType]
+			if !ok {
+				log.Printf("Skipping batch report %d: no handler for %q", i, reportType)
+				continue
+			}
+
+			// Reuse the per-type handler by presenting the element as its own request.
+			itemReq := r.Clone(r.Context())
+			itemReq.Body = io.NopCloser(bytes.NewReader(item))
+			itemReq.ContentLength = int64(len(item))
+
+			report, err := handler.Handle(itemReq)
+			if err != nil {
+				log.Printf("Skipping batch report %d (%s): %v", i, meta.Type, err)
+				continue
+			}
+
+			userAgent := meta.UserAgent
+			if userAgent == "" {
+				userAgent = r.Header.Get("User-Agent")
+			}
+			if err := reportService.SaveReport(reportType, report, userAgent); err != nil && err != database.ErrDuplicateReport {
+				log.Printf("Failed to save batch report %d (%s): %v", i, meta.Type, err)
+				continue
+			}
+			saved++
+		}
+
+		if len(items) > 0 && saved < len(items) {
+			log.Printf("Saved %d/%d batch reports", saved, len(items))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
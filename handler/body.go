@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// maxReportBodySize limits the size of a (decompressed) report body.
+const maxReportBodySize = 10 << 20
+
+// errReportTooLarge is returned when a report body exceeds maxReportBodySize.
+var errReportTooLarge = errors.New("report body too large")
+
+// gzipMagic is the header of a gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// zipMagic is the header of a zip archive.
+var zipMagic = []byte("PK\x03\x04")
+
+// readReportBody reads the request body, transparently decompressing gzip
+// payloads and extracting single-file zip archives. Gzip is detected from the
+// Content-Encoding header, a "+gzip" media type suffix (e.g.
+// application/tlsrpt+gzip) or the gzip header; zip from the archive header.
+func readReportBody(r *http.Request) ([]byte, error) {
+	br := bufio.NewReader(r.Body)
+	magic, _ := br.Peek(len(gzipMagic))
+
+	var reader io.Reader = br
+	if isGzipped(r) || bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	body, err := io.ReadAll(io.LimitReader(reader, maxReportBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxReportBodySize {
+		return nil, errReportTooLarge
+	}
+
+	if bytes.HasPrefix(body, zipMagic) {
+		return readZipBody(body)
+	}
+	return body, nil
+}
+
+// readZipBody returns the contents of the first file in a zip archive.
+func readZipBody(body []byte) ([]byte, error) {
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+
+		content, err := io.ReadAll(io.LimitReader(rc, maxReportBodySize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(content) > maxReportBodySize {
+			return nil, errReportTooLarge
+		}
+		return content, nil
+	}
+
+	return nil, errors.New("empty zip archive")
+}
+
+// isGzipped reports whether the request headers declare a gzip payload.
+func isGzipped(r *http.Request) bool {
+	if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && strings.HasSuffix(mediaType, "+gzip")
+}
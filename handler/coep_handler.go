@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// COEPReportHandler handles Cross-Origin-Embedder-Policy (COEP) reports.
+type COEPReportHandler struct{}
+
+func init() {
+	registry.MustRegister(registry.ReportType{
+		Name:           "coep",
+		ReportingTypes: []string{"coep"},
+		Description:    "Cross-Origin-Embedder-Policy violations",
+		Handler:        &COEPReportHandler{},
+		Decode:         registry.JSONDecoder[types.COEPReport](),
+	})
+}
+
+// Handle decodes a COEP report from the request body.
+func (h *COEPReportHandler) Handle(r *http.Request) (types.Report, error) {
+	var report types.COEPReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// COOPReportHandler handles Cross-Origin-Opener-Policy (COOP) reports.
+type COOPReportHandler struct{}
+
+func init() {
+	registry.MustRegister(registry.ReportType{
+		Name:           "coop",
+		ReportingTypes: []string{"coop"},
+		Description:    "Cross-Origin-Opener-Policy violations",
+		Handler:        &COOPReportHandler{},
+		Decode:         registry.JSONDecoder[types.COOPReport](),
+	})
+}
+
+// Handle decodes a COOP report from the request body.
+func (h *COOPReportHandler) Handle(r *http.Request) (types.Report, error) {
+	var report types.COOPReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
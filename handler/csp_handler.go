@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// legacyCSPContentType is the content type browsers use when sending report-uri reports.
+const legacyCSPContentType = "application/csp-report"
+
+// CSPReportHandler handles CSP violation reports.
+type CSPReportHandler struct{}
+
+func init() {
+	registry.MustRegister(registry.ReportType{
+		Name:           "csp",
+		ReportingTypes: []string{"csp-violation"},
+		Description:    "Content Security Policy violations",
+		Handler:        &CSPReportHandler{},
+		Decode:         registry.JSONDecoder[types.CSPReport](),
+	})
+}
+
+// Handle decodes a CSP report from the request body. Both the Reporting API
+// format (report-to) and the legacy report-uri format are accepted; the latter
+// is detected by its content type or its "csp-report" envelope.
+func (h *CSPReportHandler) Handle(r *http.Request) (types.Report, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var probe struct {
+		Legacy json.RawMessage `json:"csp-report"`
+	}
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return nil, err
+	}
+
+	if probe.Legacy != nil || isLegacyCSPContentType(r.Header.Get("Content-Type")) {
+		var legacy types.LegacyCSPReport
+		if err := json.Unmarshal(body, &legacy); err != nil {
+			return nil, err
+		}
+		if legacy.Body == nil {
+			return nil, errors.New("missing csp-report body")
+		}
+		report := legacy.CSPReport()
+		return &report, nil
+	}
+
+	var report types.CSPReport
+	if err := json.Unmarshal(body, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// isLegacyCSPContentType reports whether the content type is application/csp-report.
+func isLegacyCSPContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == legacyCSPContentType
+}
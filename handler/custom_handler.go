@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/vinsonio/security-report-collector/config"
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// CustomReportHandler handles reports of a user-defined type declared in configuration.
+type CustomReportHandler struct {
+	reportType types.CustomType
+	schema     *jsonschema.Schema
+}
+
+// NewCustomReportHandler compiles the declared JSON Schema and creates a handler for the type.
+func NewCustomReportHandler(def config.CustomReportType) (*CustomReportHandler, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(def.Schema))
+	if err != nil {
+		return nil, fmt.Errorf("custom report type %s: invalid schema: %w", def.Name, err)
+	}
+
+	url := "urn:report-type:" + def.Name
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(url, doc); err != nil {
+		return nil, fmt.Errorf("custom report type %s: %w", def.Name, err)
+	}
+	schema, err := compiler.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("custom report type %s: %w", def.Name, err)
+	}
+
+	return &CustomReportHandler{
+		reportType: types.CustomType{Name: def.Name, Fingerprint: def.Fingerprint},
+		schema:     schema,
+	}, nil
+}
+
+// ReportType returns the registry entry for the user-defined report type served by the handler.
+func (h *CustomReportHandler) ReportType() registry.ReportType {
+	ct := h.reportType
+	return registry.ReportType{
+		Name:        ct.Name,
+		Description: "Custom report type",
+		Handler:     h,
+		Decode: func(data []byte) (types.Report, error) {
+			if !json.Valid(data) {
+				return nil, fmt.Errorf("invalid %s report JSON", ct.Name)
+			}
+			return types.CustomReport{CustomType: ct, Data: json.RawMessage(data)}, nil
+		},
+	}
+}
+
+// Handle decodes a report from the request body and validates it against the type's schema.
+func (h *CustomReportHandler) Handle(r *http.Request) (types.Report, error) {
+	body, err := readReportBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if err := h.schema.Validate(instance); err != nil {
+		return nil, err
+	}
+
+	return &types.CustomReport{
+		CustomType: h.reportType,
+		Data:       json.RawMessage(body),
+	}, nil
+}
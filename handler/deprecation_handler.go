@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// DeprecationReportHandler handles deprecation reports.
+type DeprecationReportHandler struct{}
+
+func init() {
+	registry.MustRegister(registry.ReportType{
+		Name:           "deprecation",
+		ReportingTypes: []string{"deprecation"},
+		Description:    "Deprecated browser API usage",
+		Handler:        &DeprecationReportHandler{},
+		Decode:         registry.JSONDecoder[types.DeprecationReport](),
+	})
+}
+
+// Handle decodes a deprecation report from the request body.
+func (h *DeprecationReportHandler) Handle(r *http.Request) (types.Report, error) {
+	var report types.DeprecationReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
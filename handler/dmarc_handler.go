@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// DMARCReportHandler handles DMARC aggregate (RUA) reports.
+type DMARCReportHandler struct{}
+
+func init() {
+	registry.MustRegister(registry.ReportType{
+		Name:        "dmarc",
+		Description: "DMARC aggregate report records",
+		Handler:     &DMARCReportHandler{},
+		Decode:      registry.JSONDecoder[types.DMARCReport](),
+	})
+}
+
+// SentByServers reports that DMARC aggregate reports are sent by mail receivers.
+func (h *DMARCReportHandler) SentByServers() bool {
+	return true
+}
+
+// ContentTypes returns the media types accepted for DMARC aggregate reports.
+func (h *DMARCReportHandler) ContentTypes() []string {
+	return []string{
+		"application/xml",
+		"text/xml",
+		"application/gzip",
+		"application/x-gzip",
+		"application/zip",
+		"application/x-zip-compressed",
+		"application/octet-stream",
+	}
+}
+
+// Handle decodes a DMARC aggregate report and returns its first record.
+// CreateReport uses HandleAll so that every record is stored.
+func (h *DMARCReportHandler) Handle(r *http.Request) (types.Report, error) {
+	reports, err := h.HandleAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return reports[0], nil
+}
+
+// HandleAll decodes a raw, gzip-compressed or zipped DMARC aggregate XML report,
+// validates it and returns one report per record.
+func (h *DMARCReportHandler) HandleAll(r *http.Request) ([]types.Report, error) {
+	body, err := readReportBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var aggregate types.DMARCAggregateReport
+	if err := xml.NewDecoder(bytes.NewReader(body)).Decode(&aggregate); err != nil {
+		return nil, err
+	}
+	if err := aggregate.Validate(); err != nil {
+		return nil, err
+	}
+
+	records := aggregate.Reports()
+	reports := make([]types.Report, 0, len(records))
+	for i := range records {
+		reports = append(reports, &records[i])
+	}
+	return reports, nil
+}
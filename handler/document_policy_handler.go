@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// DocumentPolicyReportHandler handles Document-Policy violation reports.
+type DocumentPolicyReportHandler struct{}
+
+func init() {
+	registry.MustRegister(registry.ReportType{
+		Name:           "document-policy",
+		ReportingTypes: []string{"document-policy-violation"},
+		Description:    "Document-Policy violations",
+		Handler:        &DocumentPolicyReportHandler{},
+		Decode:         registry.JSONDecoder[types.DocumentPolicyReport](),
+	})
+}
+
+// Handle decodes a Document-Policy report from the request body.
+func (h *DocumentPolicyReportHandler) Handle(r *http.Request) (types.Report, error) {
+	var report types.DocumentPolicyReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// ExpectCTReportHandler handles Certificate Transparency (Expect-CT) reports.
+type ExpectCTReportHandler struct{}
+
+func init() {
+	registry.MustRegister(registry.ReportType{
+		Name:        "expect-ct",
+		Description: "Certificate Transparency (Expect-CT) reports",
+		Handler:     &ExpectCTReportHandler{},
+		Decode:      registry.JSONDecoder[types.ExpectCTReport](),
+	})
+}
+
+// Handle decodes an Expect-CT report from the request body.
+func (h *ExpectCTReportHandler) Handle(r *http.Request) (types.Report, error) {
+	var envelope struct {
+		Body *types.ExpectCTReportBody `json:"expect-ct-report"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&envelope); err != nil {
+		return nil, err
+	}
+	if envelope.Body == nil {
+		return nil, errors.New("missing expect-ct-report body")
+	}
+	return &types.ExpectCTReport{Body: *envelope.Body}, nil
+}
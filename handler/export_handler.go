@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vinsonio/security-report-collector/database"
+	"github.com/vinsonio/security-report-collector/internal/util"
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/service"
+)
+
+// Export formats.
+const (
+	exportNDJSON = "ndjson"
+	exportCSV    = "csv"
+)
+
+// exportColumns are the CSV columns of a stored report preceding its data.
+var exportColumns = []string{"id", "report_type", "hash", "created_at", "first_seen", "last_seen", "occurrences", "user_agent", "issue_id"}
+
+// reportWriter writes stored reports in an export format.
+type reportWriter interface {
+	Write(report database.StoredReport) error
+	// Close writes any buffered output.
+	Close() error
+}
+
+// ExportReports returns a new http.Handler streaming all stored reports matching
+// the filters of ListReports, newest first, in the format given by the format
+// query parameter: ndjson (the default) or csv. Reports are written as they are
+// read from the database, so exports are not limited in size.
+func ExportReports(reportService *service.ReportService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		format := query.Get("format")
+		if format == "" {
+			format = exportNDJSON
+		}
+		if format != exportNDJSON && format != exportCSV {
+			http.Error(w, fmt.Sprintf("invalid format: %q", format), http.StatusBadRequest)
+			return
+		}
+
+		filter, err := parseReportFilter(query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var writer reportWriter
+		switch format {
+		case exportCSV:
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			writer = newCSVReportWriter(w, reportService.Registry(), filter.Type)
+		default:
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			writer = newNDJSONReportWriter(w)
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="reports.%s"`, format))
+
+		count := 0
+		err = reportService.ExportReports(filter, func(report database.StoredReport) error {
+			count++
+			return writer.Write(report)
+		})
+		if err != nil && count == 0 {
+			log.Printf("failed to export reports: %v", err)
+			w.Header().Del("Content-Disposition")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		if err != nil {
+			// The response has started, so the export can only be cut short
+			log.Printf("failed to export reports after %d reports: %v", count, err)
+			return
+		}
+		if err := writer.Close(); err != nil {
+			log.Printf("failed to write export: %v", err)
+		}
+	}
+}
+
+// ndjsonReportWriter writes stored reports as newline-delimited JSON.
+type ndjsonReportWriter struct {
+	encoder *json.Encoder
+}
+
+func newNDJSONReportWriter(w io.Writer) *ndjsonReportWriter {
+	return &ndjsonReportWriter{encoder: json.NewEncoder(w)}
+}
+
+func (w *ndjsonReportWriter) Write(report database.StoredReport) error {
+	return w.encoder.Encode(report)
+}
+
+func (w *ndjsonReportWriter) Close() error {
+	return nil
+}
+
+// csvReportWriter writes stored reports as CSV. Reports of a registered report
+// type get a column per field of the decoded report, e.g. body.blockedURL;
+// other reports, including mixed types, get their JSON data in a data column.
+type csvReportWriter struct {
+	csv         *csv.Writer
+	reportTypes *registry.Registry
+	// reportType is the decoded type whose fields are flattened into columns,
+	// nil when the data is written as JSON.
+	reportType    reflect.Type
+	columns       []string
+	headerWritten bool
+}
+
+func newCSVReportWriter(w io.Writer, reportTypes *registry.Registry, reportType string) *csvReportWriter {
+	writer := &csvReportWriter{csv: csv.NewWriter(w), reportTypes: reportTypes, columns: []string{"data"}}
+	if reportType == "" {
+		return writer
+	}
+
+	// Decoding an empty report yields the type to take the columns from
+	sample, err := reportTypes.Decode(reportType, []byte("{}"))
+	if err != nil {
+		return writer
+	}
+	if columns := util.FlattenColumns(reflect.TypeOf(sample)); len(columns) > 1 || columns[0] != "" {
+		writer.reportType = reflect.TypeOf(sample)
+		writer.columns = columns
+	}
+	return writer
+}
+
+func (w *csvReportWriter) Write(report database.StoredReport) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+
+	record := []string{
+		report.ID,
+		report.ReportType,
+		report.Hash,
+		formatExportTime(report.CreatedAt),
+		formatExportTime(report.FirstSeen),
+		formatExportTime(report.LastSeen),
+		strconv.Itoa(report.Occurrences),
+		csvSafe(report.UserAgent),
+		report.IssueID,
+	}
+
+	data, err := w.data(report)
+	if err != nil {
+		return err
+	}
+	for _, value := range data {
+		record = append(record, csvSafe(value))
+	}
+	return w.csv.Write(record)
+}
+
+// data returns the data columns of a report. Reports that cannot be decoded
+// into the flattened type have empty columns.
+func (w *csvReportWriter) data(report database.StoredReport) ([]string, error) {
+	if w.reportType == nil {
+		return []string{string(report.Data)}, nil
+	}
+
+	decoded, err := w.reportTypes.Decode(report.ReportType, report.Data)
+	if err != nil || reflect.TypeOf(decoded) != w.reportType {
+		return make([]string, len(w.columns)), nil
+	}
+	return util.Flatten(decoded)
+}
+
+func (w *csvReportWriter) writeHeader() error {
+	if w.headerWritten {
+		return nil
+	}
+	w.headerWritten = true
+	return w.csv.Write(append(append([]string{}, exportColumns...), w.columns...))
+}
+
+func (w *csvReportWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// csvSafe prefixes values that spreadsheet applications would evaluate as a
+// formula with a quote. Report data is sent by browsers and cannot be trusted.
+func csvSafe(value string) string {
+	if value == "" || !strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return "'" + value
+}
+
+// formatExportTime formats a time as RFC 3339, or the zero time as an empty string.
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vinsonio/security-report-collector/database"
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/service"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// ReportHandler defines the interface for handling a specific type of report.
+// Report types register their handler with the registry package.
+type ReportHandler = registry.Handler
+
+// MultiReportHandler is implemented by report handlers whose payloads expand into
+// several reports, e.g. one per policy of a TLS-RPT aggregate report.
+type MultiReportHandler interface {
+	HandleAll(r *http.Request) ([]types.Report, error)
+}
+
+// ContentTypeHandler is implemented by report handlers that accept payloads other
+// than JSON. Requests with a Content-Type outside of ContentTypes are rejected;
+// requests without a Content-Type are passed on to the handler.
+type ContentTypeHandler interface {
+	ContentTypes() []string
+}
+
+// ServerReportHandler is implemented by report handlers for reports sent by
+// servers rather than browsers, e.g. by mail servers. Servers set no Origin or
+// Referer header, so these reports are not checked against ALLOWED_DOMAINS.
+type ServerReportHandler interface {
+	SentByServers() bool
+}
+
+func HealthCheck(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// CreateReport returns a new http.Handler for routing reports.
+func CreateReport(reportService *service.ReportService, handlers map[string]ReportHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reportType := chi.URLParam(r, "type")
+
+		handler, ok := handlers[reportType]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if !acceptsContentType(handler, r.Header.Get("Content-Type")) {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		reports, err := handleReports(handler, r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		userAgent := r.Header.Get("User-Agent")
+		for _, report := range reports {
+			if err := reportService.SaveReport(reportType, report, userAgent); err != nil {
+				if err == database.ErrDuplicateReport {
+					continue
+				}
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleReports decodes all reports contained in the request.
+func handleReports(handler ReportHandler, r *http.Request) ([]types.Report, error) {
+	if multi, ok := handler.(MultiReportHandler); ok {
+		return multi.HandleAll(r)
+	}
+
+	report, err := handler.Handle(r)
+	if err != nil {
+		return nil, err
+	}
+	return []types.Report{report}, nil
+}
+
+// acceptsContentType reports whether the handler accepts the given content type.
+func acceptsContentType(handler ReportHandler, contentType string) bool {
+	typed, ok := handler.(ContentTypeHandler)
+	if !ok || contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, accepted := range typed.ContentTypes() {
+		if mediaType == accepted {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,1002 @@
+package handler_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vinsonio/security-report-collector/config"
+	"github.com/vinsonio/security-report-collector/database"
+	"github.com/vinsonio/security-report-collector/handler"
+	databasetesting "github.com/vinsonio/security-report-collector/internal/testing"
+	"github.com/vinsonio/security-report-collector/service"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+func TestCreateReport_DuplicateHandled(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	reportService := service.NewReportService(store)
+
+	jsonStr := []byte(`{"csp-report":{}}`)
+	req, err := http.NewRequest("POST", "/reports/csp", bytes.NewBuffer(jsonStr))
+	assert.NoError(t, err)
+	req.Header.Set("User-Agent", "test-agent")
+
+	rr := httptest.NewRecorder()
+
+	reportHandlers := map[string]handler.ReportHandler{
+		"csp": &handler.CSPReportHandler{},
+	}
+
+	store.On("Save", "csp", mock.AnythingOfType("*types.CSPReport"), "test-agent", mock.AnythingOfType("string")).Return(database.ErrDuplicateReport)
+
+	router := chi.NewRouter()
+	router.Post("/reports/{type}", handler.CreateReport(reportService, reportHandlers))
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	store.AssertExpectations(t)
+}
+
+func TestCreateReport(t *testing.T) {
+	t.Run("handles valid report", func(t *testing.T) {
+		store := new(databasetesting.MockDB)
+		reportService := service.NewReportService(store)
+
+		jsonStr := []byte(`{"csp-report":{}}`)
+		req, err := http.NewRequest("POST", "/reports/csp", bytes.NewBuffer(jsonStr))
+		assert.NoError(t, err)
+		req.Header.Set("User-Agent", "test-agent")
+
+		rr := httptest.NewRecorder()
+
+		reportHandlers := map[string]handler.ReportHandler{
+			"csp": &handler.CSPReportHandler{},
+		}
+
+		store.On("Save", "csp", mock.AnythingOfType("*types.CSPReport"), "test-agent", mock.AnythingOfType("string")).Return(nil)
+
+		router := chi.NewRouter()
+		router.Post("/reports/{type}", handler.CreateReport(reportService, reportHandlers))
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("handles unknown report type", func(t *testing.T) {
+		store := new(databasetesting.MockDB)
+		reportService := service.NewReportService(store)
+
+		jsonStr := []byte(`{"type":"unknown"}`)
+		req, err := http.NewRequest("POST", "/reports/unknown", bytes.NewBuffer(jsonStr))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+
+		reportHandlers := map[string]handler.ReportHandler{
+			"csp": &handler.CSPReportHandler{},
+		}
+
+		router := chi.NewRouter()
+		router.Post("/reports/{type}", handler.CreateReport(reportService, reportHandlers))
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("handles invalid json", func(t *testing.T) {
+		store := new(databasetesting.MockDB)
+		reportService := service.NewReportService(store)
+
+		jsonStr := []byte(`invalid-json`)
+		req, err := http.NewRequest("POST", "/reports/csp", bytes.NewBuffer(jsonStr))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+
+		reportHandlers := map[string]handler.ReportHandler{
+			"csp": &handler.CSPReportHandler{},
+		}
+
+		router := chi.NewRouter()
+		router.Post("/reports/{type}", handler.CreateReport(reportService, reportHandlers))
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestCSPReportHandler_LegacyReport(t *testing.T) {
+	jsonStr := []byte(`{"csp-report":{"document-uri":"https://example.com/page","referrer":"https://example.com/","violated-directive":"script-src-elem 'self'","original-policy":"script-src 'self'; report-uri /reports/csp","disposition":"enforce","blocked-uri":"https://evil.example/x.js","status-code":200,"script-sample":"","source-file":"https://example.com/app.js","line-number":12,"column-number":4}}`)
+	req, err := http.NewRequest("POST", "/reports/csp", bytes.NewBuffer(jsonStr))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/csp-report")
+
+	report, err := (&handler.CSPReportHandler{}).Handle(req)
+	assert.NoError(t, err)
+
+	expected := &types.CSPReport{
+		URL:        "https://example.com/page",
+		ReportType: "csp-violation",
+		Body: types.CSPReportBody{
+			DocumentURL:        "https://example.com/page",
+			Disposition:        "enforce",
+			Referrer:           "https://example.com/",
+			EffectiveDirective: "script-src-elem",
+			BlockedURL:         "https://evil.example/x.js",
+			OriginalPolicy:     "script-src 'self'; report-uri /reports/csp",
+			StatusCode:         200,
+			SourceFile:         "https://example.com/app.js",
+			LineNumber:         12,
+			ColumnNumber:       4,
+		},
+	}
+	assert.Equal(t, expected, report)
+}
+
+func TestCSPReportHandler_ReportingAPIReport(t *testing.T) {
+	jsonStr := []byte(`{"type":"csp-violation","url":"https://example.com/","body":{"documentURL":"https://example.com/","effectiveDirective":"img-src","blockedURL":"https://cdn.example/a.png"}}`)
+	req, err := http.NewRequest("POST", "/reports/csp", bytes.NewBuffer(jsonStr))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/reports+json")
+
+	report, err := (&handler.CSPReportHandler{}).Handle(req)
+	assert.NoError(t, err)
+
+	csp, ok := report.(*types.CSPReport)
+	assert.True(t, ok)
+	assert.Equal(t, "img-src", csp.Body.EffectiveDirective)
+	assert.Equal(t, "https://cdn.example/a.png", csp.Body.BlockedURL)
+}
+
+func TestCSPReportHandler_LegacyContentTypeWithoutEnvelope(t *testing.T) {
+	req, err := http.NewRequest("POST", "/reports/csp", bytes.NewBufferString(`{"document-uri":"https://example.com/"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/csp-report")
+
+	_, err = (&handler.CSPReportHandler{}).Handle(req)
+	assert.Error(t, err)
+}
+
+func TestCreateReportBatch(t *testing.T) {
+	t.Run("saves each supported report", func(t *testing.T) {
+		store := new(databasetesting.MockDB)
+		reportService := service.NewReportService(store)
+
+		jsonStr := []byte(`[
+			{"type":"csp-violation","age":10,"url":"https://example.com/","user_agent":"agent-1","body":{"documentURL":"https://example.com/","effectiveDirective":"script-src-elem","blockedURL":"https://a.example/x.js"}},
+			{"type":"unknown-type","age":5,"url":"https://example.com/","user_agent":"agent-2","body":{}},
+			{"type":"csp-violation","age":3,"url":"https://example.com/b","body":{"documentURL":"https://example.com/b","effectiveDirective":"img-src","blockedURL":"https://b.example/y.png"}}
+		]`)
+		req, err := http.NewRequest("POST", "/reports", bytes.NewBuffer(jsonStr))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/reports+json")
+		req.Header.Set("User-Agent", "header-agent")
+		rr := httptest.NewRecorder()
+
+		reportHandlers := map[string]handler.ReportHandler{
+			"csp": &handler.CSPReportHandler{},
+		}
+		store.On("Save", "csp", mock.AnythingOfType("*types.CSPReport"), "agent-1", mock.AnythingOfType("string")).Return(nil).Once()
+		store.On("Save", "csp", mock.AnythingOfType("*types.CSPReport"), "header-agent", mock.AnythingOfType("string")).Return(database.ErrDuplicateReport).Once()
+
+		router := chi.NewRouter()
+		router.Post("/reports", handler.CreateReportBatch(reportService, reportHandlers))
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("tolerates failing items", func(t *testing.T) {
+		store := new(databasetesting.MockDB)
+		reportService := service.NewReportService(store)
+
+		jsonStr := []byte(`[{"type":"csp-violation","body":"not-an-object"},{"type":"csp-violation","user_agent":"ua","body":{}}]`)
+		req, err := http.NewRequest("POST", "/reports", bytes.NewBuffer(jsonStr))
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		reportHandlers := map[string]handler.ReportHandler{
+			"csp": &handler.CSPReportHandler{},
+		}
+		store.On("Save", "csp", mock.AnythingOfType("*types.CSPReport"), "ua", mock.AnythingOfType("string")).Return(errors.New("db down")).Once()
+
+		router := chi.NewRouter()
+		router.Post("/reports", handler.CreateReportBatch(reportService, reportHandlers))
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("rejects non-array payload", func(t *testing.T) {
+		store := new(databasetesting.MockDB)
+		reportService := service.NewReportService(store)
+
+		req, err := http.NewRequest("POST", "/reports", bytes.NewBufferString(`{"type":"csp-violation"}`))
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		router := chi.NewRouter()
+		router.Post("/reports", handler.CreateReportBatch(reportService, map[string]handler.ReportHandler{}))
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestNELReportHandler(t *testing.T) {
+	jsonStr := []byte(`{"age":0,"type":"network-error","url":"https://www.example.com/","body":{"sampling_fraction":1.0,"server_ip":"192.0.2.1","protocol":"http/1.1","method":"GET","status_code":0,"elapsed_time":143,"phase":"connection","type":"tcp.timed_out"}}`)
+	req, err := http.NewRequest("POST", "/reports/nel", bytes.NewBuffer(jsonStr))
+	assert.NoError(t, err)
+
+	report, err := (&handler.NELReportHandler{}).Handle(req)
+	assert.NoError(t, err)
+
+	nel, ok := report.(*types.NELReport)
+	assert.True(t, ok)
+	assert.Equal(t, "https://www.example.com/", nel.URL)
+	assert.Equal(t, "connection", nel.Body.Phase)
+	assert.Equal(t, "tcp.timed_out", nel.Body.Type)
+	assert.Equal(t, 143, nel.Body.ElapsedTime)
+	assert.Equal(t, 1.0, nel.Body.SamplingFraction)
+}
+
+func TestExpectCTReportHandler(t *testing.T) {
+	jsonStr := []byte(`{"expect-ct-report":{"date-time":"2025-01-01T00:00:00Z","hostname":"example.com","port":443,"effective-expiration-date":"2025-02-01T00:00:00Z","served-certificate-chain":["-----BEGIN CERTIFICATE-----"],"validated-certificate-chain":[],"scts":[{"version":1,"status":"unknown","source":"tls-extension","serialized_sct":"ABCD"}]}}`)
+	req, err := http.NewRequest("POST", "/reports/expect-ct", bytes.NewBuffer(jsonStr))
+	assert.NoError(t, err)
+
+	report, err := (&handler.ExpectCTReportHandler{}).Handle(req)
+	assert.NoError(t, err)
+
+	ct, ok := report.(*types.ExpectCTReport)
+	assert.True(t, ok)
+	assert.Equal(t, "example.com", ct.Body.Hostname)
+	assert.Equal(t, 443, ct.Body.Port)
+	assert.Len(t, ct.Body.SCTs, 1)
+
+	req, err = http.NewRequest("POST", "/reports/expect-ct", bytes.NewBufferString(`{"hostname":"example.com"}`))
+	assert.NoError(t, err)
+	_, err = (&handler.ExpectCTReportHandler{}).Handle(req)
+	assert.Error(t, err)
+}
+
+func TestBrowserReportHandlers(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler handler.ReportHandler
+		body    string
+		check   func(t *testing.T, report types.Report)
+	}{
+		{
+			name:    "coop",
+			handler: &handler.COOPReportHandler{},
+			body:    `{"type":"coop","url":"https://example.com/","body":{"disposition":"reporting","effectivePolicy":"same-origin","previousResponseURL":"https://other.example/","type":"navigation-to-response"}}`,
+			check: func(t *testing.T, report types.Report) {
+				r, ok := report.(*types.COOPReport)
+				assert.True(t, ok)
+				assert.Equal(t, "same-origin", r.Body.EffectivePolicy)
+				assert.Equal(t, "navigation-to-response", r.Body.Type)
+			},
+		},
+		{
+			name:    "coep",
+			handler: &handler.COEPReportHandler{},
+			body:    `{"type":"coep","url":"https://example.com/","body":{"type":"corp","blockedURL":"https://cdn.example/a.js","destination":"script","disposition":"enforce"}}`,
+			check: func(t *testing.T, report types.Report) {
+				r, ok := report.(*types.COEPReport)
+				assert.True(t, ok)
+				assert.Equal(t, "https://cdn.example/a.js", r.Body.BlockedURL)
+				assert.Equal(t, "script", r.Body.Destination)
+			},
+		},
+		{
+			name:    "document-policy",
+			handler: &handler.DocumentPolicyReportHandler{},
+			body:    `{"type":"document-policy-violation","url":"https://example.com/","body":{"featureId":"oversized-images","disposition":"report","message":"too big"}}`,
+			check: func(t *testing.T, report types.Report) {
+				r, ok := report.(*types.DocumentPolicyReport)
+				assert.True(t, ok)
+				assert.Equal(t, "oversized-images", r.Body.FeatureID)
+			},
+		},
+		{
+			name:    "permissions-policy",
+			handler: &handler.PermissionsPolicyReportHandler{},
+			body:    `{"type":"permissions-policy-violation","url":"https://example.com/","body":{"featureId":"geolocation","sourceFile":"https://example.com/app.js","lineNumber":3,"columnNumber":9,"disposition":"enforce"}}`,
+			check: func(t *testing.T, report types.Report) {
+				r, ok := report.(*types.PermissionsPolicyReport)
+				assert.True(t, ok)
+				assert.Equal(t, "geolocation", r.Body.FeatureID)
+				assert.Equal(t, 3, r.Body.LineNumber)
+			},
+		},
+		{
+			name:    "deprecation",
+			handler: &handler.DeprecationReportHandler{},
+			body:    `{"type":"deprecation","url":"https://example.com/","body":{"id":"websql","anticipatedRemoval":"2025-01-01","message":"WebSQL is deprecated"}}`,
+			check: func(t *testing.T, report types.Report) {
+				r, ok := report.(*types.DeprecationReport)
+				assert.True(t, ok)
+				assert.Equal(t, "websql", r.Body.ID)
+				assert.Equal(t, "2025-01-01", r.Body.AnticipatedRemoval)
+			},
+		},
+		{
+			name:    "intervention",
+			handler: &handler.InterventionReportHandler{},
+			body:    `{"type":"intervention","url":"https://example.com/","body":{"id":"audio-no-gesture","message":"Autoplay was blocked"}}`,
+			check: func(t *testing.T, report types.Report) {
+				r, ok := report.(*types.InterventionReport)
+				assert.True(t, ok)
+				assert.Equal(t, "audio-no-gesture", r.Body.ID)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/reports/"+tt.name, bytes.NewBufferString(tt.body))
+			assert.NoError(t, err)
+
+			report, err := tt.handler.Handle(req)
+			assert.NoError(t, err)
+			tt.check(t, report)
+		})
+	}
+}
+
+const tlsrptReport = `{
+	"organization-name": "Company-X",
+	"date-range": {"start-datetime": "2016-04-01T00:00:00Z", "end-datetime": "2016-04-01T23:59:59Z"},
+	"contact-info": "sts-reporting@company-x.example",
+	"report-id": "5065427c-23d3-47ca-b6e0-946ea0e8c4be",
+	"policies": [
+		{
+			"policy": {"policy-type": "sts", "policy-string": ["version: STSv1", "mode: testing"], "policy-domain": "company-y.example", "mx-host": ["*.mail.company-y.example"]},
+			"summary": {"total-successful-session-count": 5326, "total-failure-session-count": 303},
+			"failure-details": [{"result-type": "certificate-expired", "sending-mta-ip": "2001:db8:abcd:0012::1", "receiving-mx-hostname": "mx1.mail.company-y.example", "failed-session-count": 100}]
+		},
+		{
+			"policy": {"policy-type": "no-policy-found", "policy-domain": "company-z.example"},
+			"summary": {"total-successful-session-count": 10, "total-failure-session-count": 0}
+		}
+	]
+}`
+
+func TestTLSRPTReportHandler(t *testing.T) {
+	t.Run("stores each policy of a gzip report", func(t *testing.T) {
+		store := new(databasetesting.MockDB)
+		reportService := service.NewReportService(store)
+
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, err := gz.Write([]byte(tlsrptReport))
+		assert.NoError(t, err)
+		assert.NoError(t, gz.Close())
+
+		req, err := http.NewRequest("POST", "/reports/tlsrpt", &buf)
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/tlsrpt+gzip")
+		req.Header.Set("User-Agent", "mta")
+		rr := httptest.NewRecorder()
+
+		store.On("Save", "tlsrpt", mock.MatchedBy(func(r *types.TLSRPTReport) bool {
+			return r.Policy.Policy.PolicyDomain == "company-y.example"
+		}), "mta", mock.AnythingOfType("string")).Return(nil).Once()
+		store.On("Save", "tlsrpt", mock.MatchedBy(func(r *types.TLSRPTReport) bool {
+			return r.Policy.Policy.PolicyDomain == "company-z.example"
+		}), "mta", mock.AnythingOfType("string")).Return(nil).Once()
+
+		router := chi.NewRouter()
+		router.Post("/reports/{type}", handler.CreateReport(reportService, map[string]handler.ReportHandler{
+			"tlsrpt": &handler.TLSRPTReportHandler{},
+		}))
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("accepts uncompressed json", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/reports/tlsrpt", bytes.NewBufferString(tlsrptReport))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/tlsrpt+json")
+
+		reports, err := (&handler.TLSRPTReportHandler{}).HandleAll(req)
+		assert.NoError(t, err)
+		assert.Len(t, reports, 2)
+	})
+
+	t.Run("rejects invalid schema", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/reports/tlsrpt", bytes.NewBufferString(`{"organization-name":"x","policies":[]}`))
+		assert.NoError(t, err)
+
+		_, err = (&handler.TLSRPTReportHandler{}).Handle(req)
+		assert.Error(t, err)
+	})
+}
+
+const dmarcReport = `<?xml version="1.0" encoding="UTF-8" ?>
+<feedback>
+  <report_metadata>
+    <org_name>example.net</org_name>
+    <report_id>r-1</report_id>
+    <date_range><begin>1700000000</begin><end>1700086399</end></date_range>
+  </report_metadata>
+  <policy_published><domain>example.com</domain><p>reject</p></policy_published>
+  <record>
+    <row><source_ip>192.0.2.1</source_ip><count>2</count><policy_evaluated><disposition>none</disposition><dkim>pass</dkim><spf>pass</spf></policy_evaluated></row>
+    <identifiers><header_from>example.com</header_from></identifiers>
+    <auth_results><spf><domain>example.com</domain><result>pass</result></spf></auth_results>
+  </record>
+  <record>
+    <row><source_ip>192.0.2.2</source_ip><count>1</count><policy_evaluated><disposition>reject</disposition><dkim>fail</dkim><spf>fail</spf></policy_evaluated></row>
+    <identifiers><header_from>example.com</header_from></identifiers>
+    <auth_results><spf><domain>evil.example</domain><result>fail</result></spf></auth_results>
+  </record>
+</feedback>`
+
+func TestDMARCReportHandler(t *testing.T) {
+	zipped := func(t *testing.T) *bytes.Buffer {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		f, err := zw.Create("example.net!example.com!1700000000!1700086399.xml")
+		assert.NoError(t, err)
+		_, err = f.Write([]byte(dmarcReport))
+		assert.NoError(t, err)
+		assert.NoError(t, zw.Close())
+		return &buf
+	}
+	gzipped := func(t *testing.T) *bytes.Buffer {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, err := gz.Write([]byte(dmarcReport))
+		assert.NoError(t, err)
+		assert.NoError(t, gz.Close())
+		return &buf
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        func(t *testing.T) *bytes.Buffer
+	}{
+		{"raw xml", "application/xml", func(t *testing.T) *bytes.Buffer { return bytes.NewBufferString(dmarcReport) }},
+		{"zip attachment", "application/zip", zipped},
+		{"gzip attachment", "application/gzip", gzipped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(databasetesting.MockDB)
+			reportService := service.NewReportService(store)
+
+			req, err := http.NewRequest("POST", "/reports/dmarc", tt.body(t))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", tt.contentType)
+			rr := httptest.NewRecorder()
+
+			store.On("Save", "dmarc", mock.AnythingOfType("*types.DMARCReport"), "", mock.AnythingOfType("string")).Return(nil).Twice()
+
+			router := chi.NewRouter()
+			router.Post("/reports/{type}", handler.CreateReport(reportService, map[string]handler.ReportHandler{
+				"dmarc": &handler.DMARCReportHandler{},
+			}))
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusNoContent, rr.Code)
+			store.AssertExpectations(t)
+		})
+	}
+
+	t.Run("rejects unsupported content type", func(t *testing.T) {
+		store := new(databasetesting.MockDB)
+		reportService := service.NewReportService(store)
+
+		req, err := http.NewRequest("POST", "/reports/dmarc", bytes.NewBufferString(`{}`))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		router := chi.NewRouter()
+		router.Post("/reports/{type}", handler.CreateReport(reportService, map[string]handler.ReportHandler{
+			"dmarc": &handler.DMARCReportHandler{},
+		}))
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+		store.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects invalid xml", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/reports/dmarc", bytes.NewBufferString(`<feedback><record>`))
+		assert.NoError(t, err)
+
+		_, err = (&handler.DMARCReportHandler{}).HandleAll(req)
+		assert.Error(t, err)
+	})
+}
+
+func TestCustomReportHandler(t *testing.T) {
+	def := config.CustomReportType{
+		Name: "login-anomaly",
+		Schema: json.RawMessage(`{
+			"type": "object",
+			"required": ["app", "event"],
+			"properties": {
+				"app": {"type": "string"},
+				"event": {"type": "object", "required": ["kind"], "properties": {"kind": {"enum": ["impossible-travel", "brute-force"]}}}
+			}
+		}`),
+		Fingerprint: []string{"/app", "/event/kind"},
+	}
+	h, err := handler.NewCustomReportHandler(def)
+	assert.NoError(t, err)
+
+	t.Run("valid report", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/reports/login-anomaly", bytes.NewBufferString(`{"app":"billing","event":{"kind":"brute-force","ip":"192.0.2.1"}}`))
+		assert.NoError(t, err)
+
+		report, err := h.Handle(req)
+		assert.NoError(t, err)
+		assert.Equal(t, "login-anomaly", report.Type())
+
+		hashData, err := report.HashData()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"/app": "billing", "/event/kind": "brute-force"}, hashData)
+	})
+
+	t.Run("schema violation", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/reports/login-anomaly", bytes.NewBufferString(`{"app":"billing","event":{"kind":"unknown"}}`))
+		assert.NoError(t, err)
+
+		_, err = h.Handle(req)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid schema", func(t *testing.T) {
+		_, err := handler.NewCustomReportHandler(config.CustomReportType{
+			Name:        "broken",
+			Schema:      json.RawMessage(`{"type": 42}`),
+			Fingerprint: []string{"/a"},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestListReports(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	reportService := service.NewReportService(store)
+
+	expected := database.ReportFilter{
+		Type:      "csp",
+		From:      time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:        time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		Directive: "script-src",
+		Issue:     "01J00000000000000000000003",
+		UserAgent: "Firefox",
+		Fields:    map[string]string{"body.blockedURL": "inline"},
+		Cursor:    "01J0000000000000000000000A",
+		Limit:     10,
+	}
+	page := database.ReportPage{
+		Reports:    []database.StoredReport{{ID: "01J00000000000000000000009", ReportType: "csp", Data: json.RawMessage(`{"url":"https://example.com"}`)}},
+		NextCursor: "01J00000000000000000000009",
+	}
+	store.On("Query", expected).Return(page, nil)
+
+	router := chi.NewRouter()
+	router.Get("/api/reports", handler.ListReports(reportService))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/reports?type=csp&from=2025-01-01T00:00:00Z&to=2025-02-01T00:00:00Z&directive=script-src&issue=01J00000000000000000000003&user_agent=Firefox&data.body.blockedURL=inline&cursor=01J0000000000000000000000A&limit=10", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var body struct {
+		Reports []struct {
+			ID         string          `json:"id"`
+			ReportType string          `json:"report_type"`
+			Data       json.RawMessage `json:"data"`
+		} `json:"reports"`
+		NextCursor string `json:"next_cursor"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	if assert.Len(t, body.Reports, 1) {
+		assert.Equal(t, "csp", body.Reports[0].ReportType)
+		assert.JSONEq(t, `{"url":"https://example.com"}`, string(body.Reports[0].Data))
+	}
+	assert.Equal(t, page.NextCursor, body.NextCursor)
+	store.AssertExpectations(t)
+}
+
+func TestListReports_InvalidFilter(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	reportService := service.NewReportService(store)
+
+	router := chi.NewRouter()
+	router.Get("/api/reports", handler.ListReports(reportService))
+
+	for _, query := range []string{
+		"from=yesterday",
+		"to=2025-01-01",
+		"limit=0",
+		"limit=abc",
+		"limit=100000",
+		"cursor=not-a-ulid",
+		"data.body.x'y=1",
+		"data.=1",
+	} {
+		t.Run(query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/reports?"+query, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+	store.AssertNotCalled(t, "Query", mock.Anything)
+}
+
+func TestListReports_DatabaseError(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	reportService := service.NewReportService(store)
+	store.On("Query", mock.Anything).Return(database.ReportPage{}, errors.New("db error"))
+
+	router := chi.NewRouter()
+	router.Get("/api/reports", handler.ListReports(reportService))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/reports", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestGetReport(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	reportService := service.NewReportService(store)
+
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	stored := database.StoredReport{
+		ID:          "01J00000000000000000000002",
+		ReportType:  "csp",
+		Data:        json.RawMessage(`{"url":"https://example.com","body":{"effectiveDirective":"script-src"},"unknown":true}`),
+		UserAgent:   "UA",
+		Hash:        "h",
+		CreatedAt:   createdAt,
+		Occurrences: 3,
+		FirstSeen:   createdAt,
+		LastSeen:    createdAt.Add(time.Hour),
+		UserAgents:  []string{"UA", "UA3"},
+	}
+	related := database.StoredReport{ID: "01J00000000000000000000001", ReportType: "csp", Data: json.RawMessage(`{}`), UserAgent: "UA2", Hash: "h", CreatedAt: createdAt, Occurrences: 1, FirstSeen: createdAt, LastSeen: createdAt}
+	store.On("Get", stored.ID).Return(stored, nil)
+	store.On("Query", mock.Anything).Return(database.ReportPage{Reports: []database.StoredReport{stored, related}}, nil)
+
+	router := chi.NewRouter()
+	router.Get("/api/reports/{id}", handler.GetReport(reportService))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/reports/"+stored.ID, nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"id": "01J00000000000000000000002",
+		"report_type": "csp",
+		"user_agent": "UA",
+		"hash": "h",
+		"created_at": "2025-01-02T03:04:05Z",
+		"occurrences": 3,
+		"first_seen": "2025-01-02T03:04:05Z",
+		"last_seen": "2025-01-02T04:04:05Z",
+		"user_agents": ["UA", "UA3"],
+		"data": {"url": "https://example.com", "body": {"effectiveDirective": "script-src"}},
+		"related": [
+			{"id": "01J00000000000000000000001", "report_type": "csp", "user_agent": "UA2", "hash": "h", "created_at": "2025-01-02T03:04:05Z", "occurrences": 1, "first_seen": "2025-01-02T03:04:05Z", "last_seen": "2025-01-02T03:04:05Z"}
+		]
+	}`, rr.Body.String())
+}
+
+func TestGetReport_NotFound(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	reportService := service.NewReportService(store)
+	store.On("Get", "missing").Return(database.StoredReport{}, database.ErrReportNotFound)
+
+	router := chi.NewRouter()
+	router.Get("/api/reports/{id}", handler.GetReport(reportService))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/reports/missing", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestStats(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	reportService := service.NewReportService(store)
+
+	expected := database.StatsQuery{
+		GroupBy: database.GroupByDirective,
+		Type:    "csp",
+		From:    time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Limit:   5,
+	}
+	store.On("Stats", expected).Return([]database.StatsGroup{{Value: "script-src", Count: 120, Reports: 3}}, nil)
+
+	router := chi.NewRouter()
+	router.Get("/api/stats", handler.Stats(reportService))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/stats?group_by=directive&type=csp&from=2025-01-01T00:00:00Z&limit=5", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"group_by":"directive","groups":[{"value":"script-src","count":120,"reports":3}]}`, rr.Body.String())
+	store.AssertExpectations(t)
+}
+
+func TestStats_InvalidQuery(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	reportService := service.NewReportService(store)
+
+	router := chi.NewRouter()
+	router.Get("/api/stats", handler.Stats(reportService))
+
+	for _, query := range []string{
+		"",
+		"group_by=week",
+		"group_by=type&limit=0",
+		"group_by=type&limit=5000",
+		"group_by=type&from=yesterday",
+	} {
+		t.Run(query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/stats?"+query, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+	store.AssertNotCalled(t, "Stats", mock.Anything)
+}
+
+func TestListIssues(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	reportService := service.NewReportService(store)
+
+	expected := database.IssueFilter{Status: database.IssueOpen, Type: "csp", Directive: "script-src", Limit: 10}
+	page := database.IssuePage{Issues: []database.Issue{{ID: "01J00000000000000000000003", ReportType: "csp", Status: database.IssueOpen, Occurrences: 12}}}
+	store.On("Issues", expected).Return(page, nil)
+
+	router := chi.NewRouter()
+	router.Get("/api/issues", handler.ListIssues(reportService))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/issues?status=open&type=csp&directive=script-src&limit=10", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var body database.IssuePage
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, page, body)
+	store.AssertExpectations(t)
+
+	for _, query := range []string{"status=closed", "limit=0", "cursor=not-a-ulid"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/issues?"+query, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
+
+func TestGetIssue(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	reportService := service.NewReportService(store)
+
+	seen := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	issue := database.Issue{
+		ID:              "01J00000000000000000000003",
+		Fingerprint:     "f",
+		ReportType:      "csp",
+		Directive:       "script-src",
+		BlockedOrigin:   "https://cdn.example",
+		Status:          database.IssueAcknowledged,
+		Notes:           "n",
+		Occurrences:     2,
+		FirstSeen:       seen,
+		LastSeen:        seen,
+		StatusChangedAt: seen,
+	}
+	report := database.StoredReport{ID: "01J00000000000000000000001", ReportType: "csp", Data: json.RawMessage(`{}`), UserAgent: "UA", Hash: "h", CreatedAt: seen, Occurrences: 2, FirstSeen: seen, LastSeen: seen, IssueID: issue.ID}
+	store.On("GetIssue", issue.ID).Return(issue, nil)
+	store.On("GetIssue", "missing").Return(database.Issue{}, database.ErrIssueNotFound)
+	store.On("Query", mock.Anything).Return(database.ReportPage{Reports: []database.StoredReport{report}}, nil)
+
+	router := chi.NewRouter()
+	router.Get("/api/issues/{id}", handler.GetIssue(reportService))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/issues/"+issue.ID, nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"id": "01J00000000000000000000003",
+		"fingerprint": "f",
+		"report_type": "csp",
+		"directive": "script-src",
+		"blocked_origin": "https://cdn.example",
+		"status": "acknowledged",
+		"notes": "n",
+		"occurrences": 2,
+		"first_seen": "2025-01-02T03:04:05Z",
+		"last_seen": "2025-01-02T03:04:05Z",
+		"status_changed_at": "2025-01-02T03:04:05Z",
+		"reports": [
+			{"id": "01J00000000000000000000001", "report_type": "csp", "user_agent": "UA", "hash": "h", "created_at": "2025-01-02T03:04:05Z", "occurrences": 2, "first_seen": "2025-01-02T03:04:05Z", "last_seen": "2025-01-02T03:04:05Z", "issue_id": "01J00000000000000000000003"}
+		]
+	}`, rr.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/api/issues/missing", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestUpdateIssue(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	reportService := service.NewReportService(store)
+
+	notes := "Fixed in 1.2.3"
+	update := database.IssueUpdate{Status: database.IssueResolved, Notes: &notes}
+	store.On("UpdateIssue", "01J00000000000000000000003", update).Return(database.Issue{ID: "01J00000000000000000000003", Status: database.IssueResolved, Notes: notes}, nil)
+	store.On("UpdateIssue", "missing", mock.Anything).Return(database.Issue{}, database.ErrIssueNotFound)
+
+	router := chi.NewRouter()
+	router.Patch("/api/issues/{id}", handler.UpdateIssue(reportService))
+
+	tests := []struct {
+		name   string
+		id     string
+		body   string
+		status int
+	}{
+		{"resolve", "01J00000000000000000000003", `{"status":"resolved","notes":"Fixed in 1.2.3"}`, http.StatusOK},
+		{"not found", "missing", `{"status":"resolved"}`, http.StatusNotFound},
+		{"invalid status", "01J00000000000000000000003", `{"status":"closed"}`, http.StatusBadRequest},
+		{"unknown field", "01J00000000000000000000003", `{"state":"resolved"}`, http.StatusBadRequest},
+		{"invalid JSON", "01J00000000000000000000003", `{`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/api/issues/"+tt.id, bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, tt.status, rr.Code)
+			if tt.status == http.StatusOK {
+				var issue database.Issue
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &issue))
+				assert.Equal(t, database.IssueResolved, issue.Status)
+				assert.Equal(t, notes, issue.Notes)
+			}
+		})
+	}
+	store.AssertNumberOfCalls(t, "UpdateIssue", 2)
+}
+
+func TestExportReports_NDJSON(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	reportService := service.NewReportService(store)
+
+	reports := []database.StoredReport{
+		{ID: "01J00000000000000000000002", ReportType: "nel", Hash: "b", Data: json.RawMessage(`{"url":"https://example.com/b"}`)},
+		{ID: "01J00000000000000000000001", ReportType: "nel", Hash: "a", Data: json.RawMessage(`{"url":"https://example.com/a"}`)},
+	}
+	store.On("Export", database.ReportFilter{Type: "nel", Directive: "script-src"}, mock.Anything).Return(reports, nil)
+
+	router := chi.NewRouter()
+	router.Get("/api/reports/export", handler.ExportReports(reportService))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/reports/export?type=nel&directive=script-src", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="reports.ndjson"`, rr.Header().Get("Content-Disposition"))
+
+	lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	require.Len(t, lines, 2)
+	var first database.StoredReport
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "b", first.Hash)
+	assert.JSONEq(t, `{"url":"https://example.com/b"}`, string(first.Data))
+}
+
+func TestExportReports_CSV(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	reportService := service.NewReportService(store)
+
+	seen := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	reports := []database.StoredReport{{
+		ID: "01J00000000000000000000001", ReportType: "csp", Hash: "a", UserAgent: "=cmd()", Occurrences: 3,
+		CreatedAt: seen, FirstSeen: seen, LastSeen: seen,
+		Data: json.RawMessage(`{"url":"https://example.com/","body":{"effectiveDirective":"script-src","blockedURL":"inline","lineNumber":12}}`),
+	}}
+	store.On("Export", database.ReportFilter{Type: "csp"}, mock.Anything).Return(reports, nil).Once()
+	store.On("Export", database.ReportFilter{}, mock.Anything).Return(reports, nil).Once()
+
+	router := chi.NewRouter()
+	router.Get("/api/reports/export", handler.ExportReports(reportService))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/reports/export?format=csv&type=csp", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	row := make(map[string]string)
+	for i, column := range records[0] {
+		row[column] = records[1][i]
+	}
+	assert.Equal(t, "01J00000000000000000000001", row["id"])
+	assert.Equal(t, "2025-01-02T03:04:05Z", row["last_seen"])
+	assert.Equal(t, "3", row["occurrences"])
+	assert.Equal(t, "'=cmd()", row["user_agent"])
+	assert.Equal(t, "https://example.com/", row["url"])
+	assert.Equal(t, "script-src", row["body.effectiveDirective"])
+	assert.Equal(t, "inline", row["body.blockedURL"])
+	assert.Equal(t, "12", row["body.lineNumber"])
+
+	// Without a type, the data is kept as JSON
+	req = httptest.NewRequest(http.MethodGet, "/api/reports/export?format=csv", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	records, err = csv.NewReader(rr.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "data", records[0][len(records[0])-1])
+	assert.JSONEq(t, string(reports[0].Data), records[1][len(records[1])-1])
+}
+
+func TestExportReports_Errors(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	reportService := service.NewReportService(store)
+	store.On("Export", mock.Anything, mock.Anything).Return([]database.StoredReport{}, errors.New("db error"))
+
+	router := chi.NewRouter()
+	router.Get("/api/reports/export", handler.ExportReports(reportService))
+
+	for query, code := range map[string]int{
+		"format=xlsx":         http.StatusBadRequest,
+		"cursor=not-a-ulid":   http.StatusBadRequest,
+		"format=csv&type=csp": http.StatusInternalServerError,
+		"from=2025-01-01T00Z": http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports/export?"+query, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, code, rr.Code, query)
+	}
+}
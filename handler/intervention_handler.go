@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// InterventionReportHandler handles intervention reports.
+type InterventionReportHandler struct{}
+
+func init() {
+	registry.MustRegister(registry.ReportType{
+		Name:           "intervention",
+		ReportingTypes: []string{"intervention"},
+		Description:    "Browser interventions",
+		Handler:        &InterventionReportHandler{},
+		Decode:         registry.JSONDecoder[types.InterventionReport](),
+	})
+}
+
+// Handle decodes an intervention report from the request body.
+func (h *InterventionReportHandler) Handle(r *http.Request) (types.Report, error) {
+	var report types.InterventionReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
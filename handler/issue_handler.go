@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vinsonio/security-report-collector/database"
+	"github.com/vinsonio/security-report-collector/service"
+)
+
+// maxIssueUpdateSize limits the size of an issue update body.
+const maxIssueUpdateSize = 64 << 10
+
+// ListIssues returns a new http.Handler listing issues. Issues are filtered by
+// the status, type and directive query parameters and paginated with the
+// cursor and limit query parameters.
+func ListIssues(reportService *service.ReportService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseIssueFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		page, err := reportService.ListIssues(filter)
+		if err != nil {
+			log.Printf("failed to list issues: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, page)
+	}
+}
+
+// issueDetailResponse is the response body of GetIssue.
+type issueDetailResponse struct {
+	database.Issue
+	Reports []database.StoredReport `json:"reports"`
+}
+
+// GetIssue returns a new http.Handler returning a single issue with its most
+// recently stored reports.
+func GetIssue(reportService *service.ReportService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		detail, err := reportService.GetIssue(chi.URLParam(r, "id"))
+		if errors.Is(err, database.ErrIssueNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("failed to get issue: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, issueDetailResponse{Issue: detail.Issue, Reports: detail.Reports})
+	}
+}
+
+// UpdateIssue returns a new http.Handler changing the triage status and notes
+// of an issue from a JSON body such as {"status": "resolved", "notes": "..."}.
+func UpdateIssue(reportService *service.ReportService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var update database.IssueUpdate
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxIssueUpdateSize))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&update); err != nil {
+			http.Error(w, "invalid issue update: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := update.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		issue, err := reportService.UpdateIssue(chi.URLParam(r, "id"), update)
+		if errors.Is(err, database.ErrIssueNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("failed to update issue: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, issue)
+	}
+}
+
+// parseIssueFilter builds an issue filter from query parameters.
+func parseIssueFilter(query url.Values) (database.IssueFilter, error) {
+	filter := database.IssueFilter{
+		Status:    query.Get("status"),
+		Type:      query.Get("type"),
+		Directive: query.Get("directive"),
+		Cursor:    query.Get("cursor"),
+	}
+
+	var err error
+	if filter.Limit, err = parseLimitParam(query); err != nil {
+		return filter, err
+	}
+
+	return filter, filter.Validate()
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// NELReportHandler handles Network Error Logging reports.
+type NELReportHandler struct{}
+
+func init() {
+	registry.MustRegister(registry.ReportType{
+		Name:           "nel",
+		ReportingTypes: []string{"network-error"},
+		Description:    "Network Error Logging reports",
+		Handler:        &NELReportHandler{},
+		Decode:         registry.JSONDecoder[types.NELReport](),
+	})
+}
+
+// Handle decodes a NEL report from the request body.
+func (h *NELReportHandler) Handle(r *http.Request) (types.Report, error) {
+	var report types.NELReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
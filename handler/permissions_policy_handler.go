@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// PermissionsPolicyReportHandler handles Permissions-Policy violation reports.
+type PermissionsPolicyReportHandler struct{}
+
+func init() {
+	registry.MustRegister(registry.ReportType{
+		Name:           "permissions-policy",
+		ReportingTypes: []string{"permissions-policy-violation"},
+		Description:    "Permissions-Policy violations",
+		Handler:        &PermissionsPolicyReportHandler{},
+		Decode:         registry.JSONDecoder[types.PermissionsPolicyReport](),
+	})
+}
+
+// Handle decodes a Permissions-Policy report from the request body.
+func (h *PermissionsPolicyReportHandler) Handle(r *http.Request) (types.Report, error) {
+	var report types.PermissionsPolicyReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// TLSRPTReportHandler handles SMTP TLS Reporting (RFC 8460) aggregate reports.
+type TLSRPTReportHandler struct{}
+
+func init() {
+	registry.MustRegister(registry.ReportType{
+		Name:        "tlsrpt",
+		Description: "SMTP TLS Reporting (RFC 8460) policy results",
+		Handler:     &TLSRPTReportHandler{},
+		Decode:      registry.JSONDecoder[types.TLSRPTReport](),
+	})
+}
+
+// Handle decodes a TLS-RPT report and returns its first policy result.
+// CreateReport uses HandleAll so that every policy result is stored.
+func (h *TLSRPTReportHandler) Handle(r *http.Request) (types.Report, error) {
+	reports, err := h.HandleAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return reports[0], nil
+}
+
+// SentByServers reports that TLS-RPT reports are sent by MTAs.
+func (h *TLSRPTReportHandler) SentByServers() bool {
+	return true
+}
+
+// HandleAll decodes a plain or gzip-compressed TLS-RPT report, validates it and
+// returns one report per policy result.
+func (h *TLSRPTReportHandler) HandleAll(r *http.Request) ([]types.Report, error) {
+	body, err := readReportBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var aggregate types.TLSRPTAggregateReport
+	if err := json.Unmarshal(body, &aggregate); err != nil {
+		return nil, err
+	}
+	if err := aggregate.Validate(); err != nil {
+		return nil, err
+	}
+
+	policies := aggregate.Reports()
+	reports := make([]types.Report, 0, len(policies))
+	for i := range policies {
+		reports = append(reports, &policies[i])
+	}
+	return reports, nil
+}
@@ -1,11 +1,16 @@
 package router
 
 import (
+	"crypto/subtle"
 	"net/http"
 	"net/url"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vinsonio/security-report-collector/handler"
 )
 
 // CORSMiddleware validates the request's Origin or Referer header against a whitelist of allowed domains.
@@ -61,3 +66,44 @@ func CORSMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// ReportOriginMiddleware applies CORSMiddleware to report requests, except to
+// report types whose handler is a handler.ServerReportHandler.
+func ReportOriginMiddleware(handlers map[string]handler.ReportHandler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		checked := CORSMiddleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if h, ok := handlers[chi.URLParam(r, "type")].(handler.ServerReportHandler); ok && h.SentByServers() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			checked.ServeHTTP(w, r)
+		})
+	}
+}
+
+// APITokenMiddleware requires the API_TOKEN bearer token on API requests.
+// When API_TOKEN is not set, requests are rejected unless API_PUBLIC=true
+// opts in to an API open to anyone.
+func APITokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := os.Getenv("API_TOKEN")
+		if token == "" {
+			if public, _ := strconv.ParseBool(os.Getenv("API_PUBLIC")); !public {
+				http.Error(w, "API_TOKEN is not set", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") || subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -97,3 +97,41 @@ func TestCORSMiddleware_AllowedWildcardSubdomain(t *testing.T) {
 	r.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusOK, rec.Code)
 }
+
+func TestAPITokenMiddleware(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(APITokenMiddleware)
+	r.Get("/api/reports", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	tests := []struct {
+		name   string
+		token  string
+		public string
+		auth   string
+		status int
+	}{
+		{name: "no token configured", token: "", auth: "", status: http.StatusForbidden},
+		{name: "no token configured, public API", token: "", public: "true", auth: "", status: http.StatusOK},
+		{name: "public API with a token", token: "secret", public: "true", auth: "", status: http.StatusUnauthorized},
+		{name: "missing header", token: "secret", auth: "", status: http.StatusUnauthorized},
+		{name: "wrong token", token: "secret", auth: "Bearer wrong", status: http.StatusUnauthorized},
+		{name: "wrong scheme", token: "secret", auth: "Basic secret", status: http.StatusUnauthorized},
+		{name: "valid token", token: "secret", auth: "Bearer secret", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("API_TOKEN", tt.token)
+			t.Setenv("API_PUBLIC", tt.public)
+
+			req := httptest.NewRequest("GET", "/api/reports", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
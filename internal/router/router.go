@@ -1,10 +1,13 @@
 package router
 
 import (
+	"net/http"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/vinsonio/security-report-collector/internal/handler"
-	"github.com/vinsonio/security-report-collector/internal/service"
+	"github.com/vinsonio/security-report-collector/handler"
+	"github.com/vinsonio/security-report-collector/internal/ui"
+	"github.com/vinsonio/security-report-collector/service"
 )
 
 func New(reportService *service.ReportService, reportHandlers map[string]handler.ReportHandler) *chi.Mux {
@@ -15,10 +18,29 @@ func New(reportService *service.ReportService, reportHandlers map[string]handler
 
 	r.Get("/healthz", handler.HealthCheck)
 
-	r.Group(func(r chi.Router) {
-		r.Use(CORSMiddleware)
-		r.Post("/reports/{type}", handler.CreateReport(reportService, reportHandlers))
+	r.With(CORSMiddleware).Post("/reports", handler.CreateReportBatch(reportService, reportHandlers))
+	r.With(ReportOriginMiddleware(reportHandlers)).Post("/reports/{type}", handler.CreateReport(reportService, reportHandlers))
+
+	// The read API and the dashboard need a database implementing database.Reader
+	if !reportService.Readable() {
+		return r
+	}
+
+	r.Route("/api", func(r chi.Router) {
+		r.Use(APITokenMiddleware)
+		r.Get("/reports", handler.ListReports(reportService))
+		r.Get("/reports/export", handler.ExportReports(reportService))
+		r.Get("/reports/{id}", handler.GetReport(reportService))
+		r.Get("/stats", handler.Stats(reportService))
+		r.Get("/issues", handler.ListIssues(reportService))
+		r.Get("/issues/{id}", handler.GetIssue(reportService))
+		r.Patch("/issues/{id}", handler.UpdateIssue(reportService))
+	})
+
+	r.Get("/ui", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ui/", http.StatusMovedPermanently)
 	})
+	r.Handle("/ui/*", http.StripPrefix("/ui", ui.Handler()))
 
 	return r
 }
@@ -9,11 +9,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
-	"github.com/vinsonio/security-report-collector/internal/handler"
-	"github.com/vinsonio/security-report-collector/internal/service"
+	"github.com/vinsonio/security-report-collector/database"
+	"github.com/vinsonio/security-report-collector/handler"
 	databasetesting "github.com/vinsonio/security-report-collector/internal/testing"
-	cachetesting "github.com/vinsonio/security-report-collector/internal/testing/cache"
-	"github.com/vinsonio/security-report-collector/internal/types"
+	"github.com/vinsonio/security-report-collector/service"
+	"github.com/vinsonio/security-report-collector/types"
 )
 
 // simple handler implementation that returns a fixed payload
@@ -31,8 +31,7 @@ func (m *mockReport) HashData() (interface{}, error) { return map[string]string{
 
 func newTestServer(t *testing.T) http.Handler {
 	store := new(databasetesting.MockDB)
-	cache := new(cachetesting.MockCache)
-	svc := service.NewReportService(store, cache, false)
+	svc := service.NewReportService(store)
 
 	handlers := map[string]handler.ReportHandler{
 		"csp": okHandler{},
@@ -54,9 +53,8 @@ func TestRouter_CreateReport_CORSAllowed(t *testing.T) {
 
 	// Build router with expectations on DB Save
 	store := new(databasetesting.MockDB)
-	cache := new(cachetesting.MockCache)
 	store.On("Save", "csp", mock.Anything, mock.Anything, mock.AnythingOfType("string")).Return(nil)
-	svc := service.NewReportService(store, cache, false)
+	svc := service.NewReportService(store)
 	mux := New(svc, map[string]handler.ReportHandler{"csp": okHandler{}})
 
 	req := httptest.NewRequest(http.MethodPost, "/reports/csp", strings.NewReader("{}"))
@@ -80,11 +78,36 @@ func TestRouter_CreateReport_CORSForbidden(t *testing.T) {
 	assert.Equal(t, http.StatusForbidden, w.Code)
 }
 
+// serverHandler handles reports sent by servers.
+type serverHandler struct{ okHandler }
+
+func (serverHandler) SentByServers() bool { return true }
+
+func TestRouter_CreateReport_ServerReportsSkipOriginCheck(t *testing.T) {
+	t.Setenv("ALLOWED_DOMAINS", "example.com")
+
+	store := new(databasetesting.MockDB)
+	store.On("Save", "tlsrpt", mock.Anything, mock.Anything, mock.AnythingOfType("string")).Return(nil)
+	svc := service.NewReportService(store)
+	mux := New(svc, map[string]handler.ReportHandler{"csp": okHandler{}, "tlsrpt": serverHandler{}})
+
+	// Mail servers send no Origin or Referer header
+	req := httptest.NewRequest(http.MethodPost, "/reports/tlsrpt", strings.NewReader("{}"))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	store.AssertExpectations(t)
+
+	req = httptest.NewRequest(http.MethodPost, "/reports/csp", strings.NewReader("{}"))
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestRouter_CreateReport_MissingHandler(t *testing.T) {
 	// No handler registered for type "x"
 	store := new(databasetesting.MockDB)
-	cache := new(cachetesting.MockCache)
-	svc := service.NewReportService(store, cache, false)
+	svc := service.NewReportService(store)
 	mux := New(svc, map[string]handler.ReportHandler{})
 
 	req := httptest.NewRequest(http.MethodPost, "/reports/x", strings.NewReader("{}"))
@@ -95,3 +118,117 @@ func TestRouter_CreateReport_MissingHandler(t *testing.T) {
 	mux.ServeHTTP(w, req)
 	require.Equal(t, http.StatusNotFound, w.Code)
 }
+
+func TestRouter_CreateReportBatch(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	store.On("Save", "csp", mock.Anything, "ua", mock.AnythingOfType("string")).Return(nil)
+	svc := service.NewReportService(store)
+	mux := New(svc, map[string]handler.ReportHandler{"csp": okHandler{}})
+
+	req := httptest.NewRequest(http.MethodPost, "/reports", strings.NewReader(`[{"type":"csp-violation","user_agent":"ua","body":{}}]`))
+	w := httptest.NewRecorder()
+
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	store.AssertExpectations(t)
+}
+
+func TestRouter_ListReports(t *testing.T) {
+	t.Setenv("ALLOWED_DOMAINS", "example.com")
+	t.Setenv("API_TOKEN", "secret")
+
+	store := new(databasetesting.MockDB)
+	store.On("Query", mock.Anything).Return(database.ReportPage{Reports: []database.StoredReport{}}, nil)
+	svc := service.NewReportService(store)
+	mux := New(svc, map[string]handler.ReportHandler{"csp": okHandler{}})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/reports", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// The read API is not subject to the report origin whitelist.
+	req = httptest.NewRequest(http.MethodGet, "/api/reports", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"reports":[]}`, w.Body.String())
+}
+
+func TestRouter_GetReport(t *testing.T) {
+	t.Setenv("API_PUBLIC", "true")
+
+	store := new(databasetesting.MockDB)
+	store.On("Get", "missing").Return(database.StoredReport{}, database.ErrReportNotFound)
+	svc := service.NewReportService(store)
+	mux := New(svc, map[string]handler.ReportHandler{"csp": okHandler{}})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/reports/missing", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	store.AssertExpectations(t)
+}
+
+func TestRouter_ExportReports(t *testing.T) {
+	t.Setenv("API_PUBLIC", "true")
+
+	store := new(databasetesting.MockDB)
+	store.On("Export", database.ReportFilter{}, mock.Anything).Return([]database.StoredReport{}, nil)
+	svc := service.NewReportService(store)
+	mux := New(svc, map[string]handler.ReportHandler{"csp": okHandler{}})
+
+	// The export is not routed to GetReport
+	req := httptest.NewRequest(http.MethodGet, "/api/reports/export", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	store.AssertExpectations(t)
+}
+
+func TestRouter_UpdateIssue(t *testing.T) {
+	t.Setenv("API_TOKEN", "secret")
+
+	store := new(databasetesting.MockDB)
+	store.On("UpdateIssue", "01J00000000000000000000003", database.IssueUpdate{Status: database.IssueIgnored}).
+		Return(database.Issue{ID: "01J00000000000000000000003", Status: database.IssueIgnored}, nil)
+	svc := service.NewReportService(store)
+	mux := New(svc, map[string]handler.ReportHandler{"csp": okHandler{}})
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/issues/01J00000000000000000000003", strings.NewReader(`{"status":"ignored"}`))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req = httptest.NewRequest(http.MethodPatch, "/api/issues/01J00000000000000000000003", strings.NewReader(`{"status":"ignored"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	store.AssertExpectations(t)
+}
+
+func TestRouter_UI(t *testing.T) {
+	t.Setenv("API_TOKEN", "secret")
+	r := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/ui", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/ui/", w.Header().Get("Location"))
+
+	// The dashboard itself is served without the API token
+	req = httptest.NewRequest(http.MethodGet, "/ui/", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Security Report Collector")
+
+	req = httptest.NewRequest(http.MethodGet, "/ui/app.js", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
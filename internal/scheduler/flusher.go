@@ -3,13 +3,13 @@ package scheduler
 import (
 	"log"
 
-	"github.com/vinsonio/security-report-collector/internal/queue"
-	"github.com/vinsonio/security-report-collector/internal/types"
+	"github.com/vinsonio/security-report-collector/database"
+	"github.com/vinsonio/security-report-collector/queue"
 )
 
 // Database is the interface for database operations used by the flusher.
 type Database interface {
-	Save(reportType string, report types.Report, userAgent string, hash string) error
+	Record(occurrence database.Occurrence) error
 }
 
 // BatchFlusher is responsible for flushing queued reports to the database.
@@ -28,7 +28,9 @@ func NewBatchFlusher(q queue.Queue, db Database, batchSize int) *BatchFlusher {
 	}
 }
 
-// Flush dequeues and persists up to batchSize reports from the queue.
+// Flush dequeues and persists up to batchSize reports from the queue. Reports
+// the database fails to record are enqueued again for the next flush. Queues
+// implementing queue.Acknowledger are acknowledged once the batch is recorded.
 func (f *BatchFlusher) Flush() error {
 	envelopes, err := f.queue.DequeueN(f.batchSize)
 	if err != nil {
@@ -40,19 +42,83 @@ func (f *BatchFlusher) Flush() error {
 		return nil
 	}
 
-	log.Printf("Flushing %d reports to database", len(envelopes))
+	occurrences := mergeEnvelopes(envelopes)
+	log.Printf("Flushing %d reports (%d distinct) to database", len(envelopes), len(occurrences))
 
-	successCount := 0
-	for _, envelope := range envelopes {
-		err := f.database.Save(envelope.Type, envelope.Report, envelope.UserAgent, envelope.Hash)
+	failed := make(map[string]bool)
+	keys := dedupKeys(envelopes)
+	for i, occurrence := range occurrences {
+		err := f.database.Record(occurrence)
 		if err != nil {
-			log.Printf("Failed to save report (hash: %s, type: %s): %v", envelope.Hash, envelope.Type, err)
+			log.Printf("Failed to save report (hash: %s, type: %s): %v", occurrence.Hash, occurrence.ReportType, err)
 			// Continue with other reports - don't fail the entire batch
-		} else {
-			successCount++
+			failed[keys[i]] = true
+		}
+	}
+
+	for _, envelope := range envelopes {
+		if !failed[envelope.DedupKey()] {
+			continue
+		}
+		if err := f.queue.Enqueue(envelope); err != nil {
+			if acknowledger, ok := f.queue.(queue.Acknowledger); ok {
+				// Keep the whole batch queued rather than lose the failed reports
+				if releaseErr := acknowledger.Release(); releaseErr != nil {
+					log.Printf("Failed to release queued reports: %v", releaseErr)
+				}
+			}
+			return err
 		}
 	}
 
-	log.Printf("Successfully flushed %d/%d reports", successCount, len(envelopes))
+	if acknowledger, ok := f.queue.(queue.Acknowledger); ok {
+		if err := acknowledger.Ack(); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Successfully flushed %d/%d reports", len(occurrences)-len(failed), len(occurrences))
 	return nil
 }
+
+// dedupKeys returns the distinct dedup keys of envelopes, in the order of the
+// occurrences returned by mergeEnvelopes.
+func dedupKeys(envelopes []*queue.ReportEnvelope) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, envelope := range envelopes {
+		if !seen[envelope.DedupKey()] {
+			seen[envelope.DedupKey()] = true
+			keys = append(keys, envelope.DedupKey())
+		}
+	}
+	return keys
+}
+
+// mergeEnvelopes merges envelopes with the same dedup key into a single
+// occurrence, keeping the order in which keys first appear in the batch.
+func mergeEnvelopes(envelopes []*queue.ReportEnvelope) []database.Occurrence {
+	var occurrences []database.Occurrence
+	index := make(map[string]int)
+
+	for _, envelope := range envelopes {
+		occurrence := database.Occurrence{
+			ReportType: envelope.Type,
+			Report:     envelope.Report,
+			Hash:       envelope.Hash,
+			UserAgents: []string{envelope.UserAgent},
+			Count:      envelope.Occurrences(),
+			FirstSeen:  envelope.Timestamp,
+			LastSeen:   envelope.LastSeenAt(),
+		}
+
+		if i, ok := index[envelope.DedupKey()]; ok {
+			occurrences[i].Merge(occurrence)
+			continue
+		}
+		index[envelope.DedupKey()] = len(occurrences)
+		occurrences = append(occurrences, occurrence)
+	}
+
+	return occurrences
+}
@@ -0,0 +1,117 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vinsonio/security-report-collector/database"
+	_ "github.com/vinsonio/security-report-collector/handler"
+	databasetesting "github.com/vinsonio/security-report-collector/internal/testing"
+	"github.com/vinsonio/security-report-collector/queue"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// batchQueue is a queue returning a fixed batch, bypassing the merging of InMemoryQueue.
+type batchQueue struct {
+	*queue.InMemoryQueue
+	batch []*queue.ReportEnvelope
+}
+
+func (q *batchQueue) DequeueN(n int) ([]*queue.ReportEnvelope, error) {
+	batch := q.batch
+	q.batch = nil
+	return batch, nil
+}
+
+func TestBatchFlusher_MergesDuplicateHashes(t *testing.T) {
+	first := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	report := types.NELReport{URL: "https://example.com"}
+	other := types.NELReport{URL: "https://example.org"}
+
+	q := &batchQueue{InMemoryQueue: queue.NewInMemoryQueue(), batch: []*queue.ReportEnvelope{
+		{Type: "nel", UserAgent: "UA", Hash: "a", Report: report, Timestamp: first.Add(time.Minute)},
+		{Type: "nel", UserAgent: "UA", Hash: "b", Report: other, Timestamp: first},
+		{Type: "nel", UserAgent: "UA2", Hash: "a", Report: report, Timestamp: first, Count: 3, LastSeen: first.Add(time.Hour)},
+	}}
+
+	store := new(databasetesting.MockDB)
+	store.On("Record", database.Occurrence{
+		ReportType: "nel",
+		Report:     report,
+		Hash:       "a",
+		UserAgents: []string{"UA", "UA2"},
+		Count:      4,
+		FirstSeen:  first,
+		LastSeen:   first.Add(time.Hour),
+	}).Return(nil).Once()
+	store.On("Record", database.Occurrence{
+		ReportType: "nel",
+		Report:     other,
+		Hash:       "b",
+		UserAgents: []string{"UA"},
+		Count:      1,
+		FirstSeen:  first,
+		LastSeen:   first,
+	}).Return(errors.New("db error")).Once()
+
+	flusher := NewBatchFlusher(q, store, 10)
+	require.NoError(t, flusher.Flush())
+	store.AssertExpectations(t)
+
+	// The report that failed is queued again
+	size, err := q.InMemoryQueue.Size()
+	require.NoError(t, err)
+	require.Equal(t, 1, size)
+	requeued, err := q.InMemoryQueue.DequeueN(10)
+	require.NoError(t, err)
+	require.Equal(t, "b", requeued[0].Hash)
+
+	// Nothing left to flush
+	require.NoError(t, flusher.Flush())
+	store.AssertNumberOfCalls(t, "Record", 2)
+	store.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBatchFlusher_AcknowledgesFileQueue(t *testing.T) {
+	dir := t.TempDir()
+	q, err := queue.NewFileQueue(dir, 0)
+	require.NoError(t, err)
+	report := types.NELReport{URL: "https://example.com"}
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", Hash: "a", Report: report, Timestamp: time.Now()}))
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", Hash: "b", Report: report, Timestamp: time.Now()}))
+
+	store := new(databasetesting.MockDB)
+	store.On("Record", mock.MatchedBy(func(o database.Occurrence) bool { return o.Hash == "a" })).Return(nil).Once()
+	store.On("Record", mock.MatchedBy(func(o database.Occurrence) bool { return o.Hash == "b" })).Return(errors.New("db error")).Once()
+
+	require.NoError(t, NewBatchFlusher(q, store, 10).Flush())
+	store.AssertExpectations(t)
+	require.NoError(t, q.Close())
+
+	// Only the report that failed is still queued after a restart
+	q, err = queue.NewFileQueue(dir, 0)
+	require.NoError(t, err)
+	defer q.Close()
+	envelopes, err := q.DequeueN(10)
+	require.NoError(t, err)
+	require.Len(t, envelopes, 1)
+	require.Equal(t, "b", envelopes[0].Hash)
+}
+
+func TestMergeEnvelopes_DedupWindows(t *testing.T) {
+	first := time.Date(2025, 1, 2, 23, 59, 0, 0, time.UTC)
+	report := types.NELReport{URL: "https://example.com"}
+
+	occurrences := mergeEnvelopes([]*queue.ReportEnvelope{
+		{Type: "nel", UserAgent: "UA", Hash: "a", Key: "a/1", Report: report, Timestamp: first},
+		{Type: "nel", UserAgent: "UA", Hash: "a", Key: "a/2", Report: report, Timestamp: first.Add(2 * time.Minute)},
+		{Type: "nel", UserAgent: "UA", Hash: "a", Key: "a/1", Report: report, Timestamp: first.Add(time.Second)},
+	})
+
+	require.Len(t, occurrences, 2)
+	require.Equal(t, 2, occurrences[0].Count)
+	require.Equal(t, first.Add(2*time.Minute), occurrences[1].FirstSeen)
+}
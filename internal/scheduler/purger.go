@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/vinsonio/security-report-collector/database"
+)
+
+// PurgeDatabase is the interface for database operations used by the purger.
+type PurgeDatabase interface {
+	Purge(policy database.RetentionPolicy) (database.PurgeResult, error)
+}
+
+// Purger deletes stored reports expired by a retention policy.
+type Purger struct {
+	database PurgeDatabase
+	policy   database.RetentionPolicy
+}
+
+// NewPurger creates a new purger.
+func NewPurger(db PurgeDatabase, policy database.RetentionPolicy) *Purger {
+	return &Purger{
+		database: db,
+		policy:   policy,
+	}
+}
+
+// Purge deletes the expired reports and returns the number of deleted rows.
+func (p *Purger) Purge() (database.PurgeResult, error) {
+	start := time.Now()
+	result, err := p.database.Purge(p.policy)
+	if err != nil {
+		return result, fmt.Errorf("purge failed after deleting %d rows: %w", result.Total(), err)
+	}
+
+	log.Printf("Purged %d expired and %d excess reports and %d issues in %v",
+		result.Expired, result.Excess, result.Issues, time.Since(start).Round(time.Millisecond))
+	return result, nil
+}
+
+// Run purges the expired reports; it is the job run by Scheduler.
+func (p *Purger) Run() error {
+	_, err := p.Purge()
+	return err
+}
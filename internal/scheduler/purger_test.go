@@ -0,0 +1,37 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vinsonio/security-report-collector/database"
+	databasetesting "github.com/vinsonio/security-report-collector/internal/testing"
+)
+
+func TestPurger_Purge(t *testing.T) {
+	policy := database.RetentionPolicy{MaxAge: 24 * time.Hour, BatchSize: 10}
+
+	store := new(databasetesting.MockDB)
+	store.On("Purge", policy).Return(database.PurgeResult{Expired: 3, Issues: 1}, nil).Once()
+
+	result, err := NewPurger(store, policy).Purge()
+	require.NoError(t, err)
+	assert.Equal(t, database.PurgeResult{Expired: 3, Issues: 1}, result)
+	store.AssertExpectations(t)
+}
+
+func TestPurger_RunError(t *testing.T) {
+	policy := database.RetentionPolicy{MaxReports: 100}
+
+	store := new(databasetesting.MockDB)
+	store.On("Purge", policy).Return(database.PurgeResult{Excess: 2}, errors.New("db error")).Once()
+
+	err := NewPurger(store, policy).Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "after deleting 2 rows")
+	assert.Contains(t, err.Error(), "db error")
+	store.AssertExpectations(t)
+}
@@ -4,12 +4,12 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/mock"
-	"github.com/vinsonio/security-report-collector/internal/config"
-	"github.com/vinsonio/security-report-collector/internal/database"
-	"github.com/vinsonio/security-report-collector/internal/types"
+	"github.com/vinsonio/security-report-collector/config"
+	"github.com/vinsonio/security-report-collector/database"
+	"github.com/vinsonio/security-report-collector/types"
 )
 
-// MockDB is a mock of database.DB.
+// MockDB is a mock of database.Store.
 type MockDB struct {
 	mock.Mock
 }
@@ -20,9 +20,68 @@ func (m *MockDB) Save(reportType string, report types.Report, userAgent, hash st
 	return args.Error(0)
 }
 
-// DB is an interface that extends the database.DB interface with testing-specific methods.
+// Record is a mock of the Record method.
+func (m *MockDB) Record(occurrence database.Occurrence) error {
+	args := m.Called(occurrence)
+	return args.Error(0)
+}
+
+// Query is a mock of the Query method.
+func (m *MockDB) Query(filter database.ReportFilter) (database.ReportPage, error) {
+	args := m.Called(filter)
+	return args.Get(0).(database.ReportPage), args.Error(1)
+}
+
+// Export is a mock of the Export method. The reports returned by the mock are passed to fn.
+func (m *MockDB) Export(filter database.ReportFilter, fn func(database.StoredReport) error) error {
+	args := m.Called(filter, fn)
+	for _, r := range args.Get(0).([]database.StoredReport) {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+// Get is a mock of the Get method.
+func (m *MockDB) Get(id string) (database.StoredReport, error) {
+	args := m.Called(id)
+	return args.Get(0).(database.StoredReport), args.Error(1)
+}
+
+// Stats is a mock of the Stats method.
+func (m *MockDB) Stats(query database.StatsQuery) ([]database.StatsGroup, error) {
+	args := m.Called(query)
+	return args.Get(0).([]database.StatsGroup), args.Error(1)
+}
+
+// Issues is a mock of the Issues method.
+func (m *MockDB) Issues(filter database.IssueFilter) (database.IssuePage, error) {
+	args := m.Called(filter)
+	return args.Get(0).(database.IssuePage), args.Error(1)
+}
+
+// GetIssue is a mock of the GetIssue method.
+func (m *MockDB) GetIssue(id string) (database.Issue, error) {
+	args := m.Called(id)
+	return args.Get(0).(database.Issue), args.Error(1)
+}
+
+// UpdateIssue is a mock of the UpdateIssue method.
+func (m *MockDB) UpdateIssue(id string, update database.IssueUpdate) (database.Issue, error) {
+	args := m.Called(id, update)
+	return args.Get(0).(database.Issue), args.Error(1)
+}
+
+// Purge is a mock of the Purge method.
+func (m *MockDB) Purge(policy database.RetentionPolicy) (database.PurgeResult, error) {
+	args := m.Called(policy)
+	return args.Get(0).(database.PurgeResult), args.Error(1)
+}
+
+// DB is an interface that extends the database.Store interface with testing-specific methods.
 type DB interface {
-	database.DB
+	database.Store
 	Count(t *testing.T) int
 }
 
@@ -42,17 +101,24 @@ func GetDBForTest(t *testing.T) DB {
 
 	switch d := db.(type) {
 	case *database.SQLiteDB:
-		_, err := d.DB.Exec("DELETE FROM reports")
-		if err != nil {
-			t.Fatalf("failed to truncate reports table: %v", err)
+		for _, table := range []string{"reports", "issues"} {
+			if _, err := d.DB.Exec("DELETE FROM " + table); err != nil {
+				t.Fatalf("failed to truncate %s table: %v", table, err)
+			}
 		}
 		return &sqliteDB{d}
 	case *database.MySQLDB:
-		_, err := d.DB.Exec("TRUNCATE TABLE reports")
-		if err != nil {
-			t.Fatalf("failed to truncate reports table: %v", err)
+		for _, table := range []string{"reports", "issues"} {
+			if _, err := d.DB.Exec("TRUNCATE TABLE " + table); err != nil {
+				t.Fatalf("failed to truncate %s table: %v", table, err)
+			}
 		}
 		return &mysqlDB{d}
+	case *database.PostgresDB:
+		if _, err := d.DB.Exec("TRUNCATE TABLE reports, issues"); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+		return &postgresDB{d}
 	default:
 		t.Fatalf("Unsupported db type for testing: %T", d)
 		return nil
@@ -84,3 +150,16 @@ func (s *mysqlDB) Count(t *testing.T) int {
 	}
 	return count
 }
+
+type postgresDB struct {
+	*database.PostgresDB
+}
+
+func (s *postgresDB) Count(t *testing.T) int {
+	var count int
+	err := s.DB.QueryRow("SELECT COUNT(*) FROM reports").Scan(&count)
+	if err != nil {
+		t.Fatalf("Failed to count reports: %v", err)
+	}
+	return count
+}
@@ -0,0 +1,323 @@
+// Read-only dashboard for the collector's /api endpoints.
+(function () {
+  "use strict";
+
+  const apiBase = new URL("../api/", window.location.href);
+  const tokenKey = "report-collector-api-token";
+  const timelineLimits = { hour: 48, day: 30 };
+
+  const $ = (selector) => document.querySelector(selector);
+  const filters = $("#filters");
+  let nextCursor = "";
+
+  // api fetches a JSON document from the read API, asking for the API token on 401.
+  async function api(path, params) {
+    const url = new URL(path, apiBase);
+    for (const [key, value] of Object.entries(params || {})) {
+      if (value !== "" && value !== undefined) {
+        url.searchParams.set(key, value);
+      }
+    }
+
+    const headers = {};
+    const token = sessionStorage.getItem(tokenKey);
+    if (token) {
+      headers.Authorization = "Bearer " + token;
+    }
+
+    const response = await fetch(url, { headers });
+    if (response.status === 401) {
+      // Retry if another request already asked for a new token in the meantime
+      if (sessionStorage.getItem(tokenKey) !== token || askToken()) {
+        return api(path, params);
+      }
+    }
+    if (!response.ok) {
+      throw new Error((await response.text()) || response.statusText);
+    }
+    return response.json();
+  }
+
+  function askToken() {
+    const token = window.prompt("API token");
+    if (token === null) {
+      return false;
+    }
+    sessionStorage.setItem(tokenKey, token);
+    return true;
+  }
+
+  function el(tag, text, attrs) {
+    const node = document.createElement(tag);
+    if (text !== undefined && text !== null) {
+      node.textContent = text;
+    }
+    for (const [key, value] of Object.entries(attrs || {})) {
+      node.setAttribute(key, value);
+    }
+    return node;
+  }
+
+  function showError(err) {
+    const box = $("#error");
+    box.textContent = err ? String(err.message || err) : "";
+    box.hidden = !err;
+  }
+
+  function formatTime(value) {
+    if (!value || value.startsWith("0001-")) {
+      return "";
+    }
+    return new Date(value).toLocaleString();
+  }
+
+  // toRFC3339 converts a datetime-local input value to an RFC 3339 timestamp.
+  function toRFC3339(value) {
+    return value ? new Date(value).toISOString() : "";
+  }
+
+  // summary extracts the columns shown in the violations table from report data.
+  function summary(report) {
+    const data = report.data || {};
+    const body = data.body || {};
+    return {
+      directive: body.effectiveDirective || body.featureId || body.id || "",
+      blocked: body.blockedURL || "",
+      document: body.documentURL || data.url || "",
+    };
+  }
+
+  function statsParams() {
+    const form = new FormData(filters);
+    return {
+      type: form.get("type"),
+      from: toRFC3339(form.get("from")),
+      to: toRFC3339(form.get("to")),
+    };
+  }
+
+  function reportParams() {
+    const form = new FormData(filters);
+    return Object.assign(statsParams(), {
+      user_agent: form.get("user_agent"),
+      directive: form.get("directive"),
+      "data.body.blockedURL": form.get("blocked"),
+    });
+  }
+
+  async function loadTypes() {
+    const stats = await api("stats", { group_by: "type", limit: 100 });
+    const select = filters.elements.type;
+    for (const group of stats.groups) {
+      if (group.value) {
+        select.append(el("option", group.value, { value: group.value }));
+      }
+    }
+  }
+
+  async function loadReports(append) {
+    const params = reportParams();
+    params.limit = 50;
+    if (append) {
+      params.cursor = nextCursor;
+    }
+
+    const page = await api("reports", params);
+    const tbody = $("#reports tbody");
+    if (!append) {
+      tbody.replaceChildren();
+    }
+
+    for (const report of page.reports) {
+      const s = summary(report);
+      const row = el("tr");
+      row.append(
+        el("td", formatTime(report.last_seen || report.created_at)),
+        el("td", report.report_type),
+        el("td", s.directive),
+        el("td", s.blocked, { title: s.blocked }),
+        el("td", s.document, { title: s.document }),
+        el("td", String(report.occurrences || 1), { class: "number" })
+      );
+      row.addEventListener("click", () => {
+        window.location.hash = "#/reports/" + encodeURIComponent(report.id);
+      });
+      tbody.append(row);
+    }
+
+    nextCursor = page.next_cursor || "";
+    $("#more").hidden = !nextCursor;
+    $("#empty").hidden = tbody.children.length > 0;
+  }
+
+  async function loadDirectives() {
+    const params = statsParams();
+    params.group_by = "directive";
+    params.limit = 15;
+
+    const stats = await api("stats", params);
+    const container = $("#directives");
+    container.replaceChildren();
+
+    const groups = stats.groups.filter((group) => group.value);
+    if (groups.length === 0) {
+      container.append(el("p", "No directives in range.", { class: "muted" }));
+      return;
+    }
+
+    const max = Math.max(...groups.map((group) => group.count));
+    const list = el("div", null, { class: "breakdown" });
+    for (const group of groups) {
+      const link = el("a", group.value, { href: "#/", title: group.value });
+      link.addEventListener("click", (event) => {
+        event.preventDefault();
+        filters.elements.directive.value = group.value;
+        refresh();
+      });
+      const bar = el("div", null, { class: "bar" });
+      bar.style.width = Math.max(2, (group.count / max) * 100) + "%";
+      list.append(link, bar, el("span", group.count.toLocaleString(), { class: "number" }));
+    }
+    container.append(list);
+  }
+
+  async function loadTimeline() {
+    const bucket = document.querySelector("input[name=bucket]:checked").value;
+    const params = statsParams();
+    params.group_by = bucket;
+    params.limit = timelineLimits[bucket];
+
+    const stats = await api("stats", params);
+    drawTimeline($("#timeline"), stats.groups);
+  }
+
+  // drawTimeline renders the stats groups as an SVG bar chart.
+  function drawTimeline(container, groups) {
+    container.replaceChildren();
+    if (groups.length === 0) {
+      container.append(el("p", "No reports in range.", { class: "muted" }));
+      return;
+    }
+
+    const ns = "http://www.w3.org/2000/svg";
+    const width = 800;
+    const height = 200;
+    const labelHeight = 20;
+    // Time buckets count the new reports first stored in them
+    const max = Math.max(...groups.map((group) => group.reports));
+    const barWidth = width / groups.length;
+
+    const svg = document.createElementNS(ns, "svg");
+    svg.setAttribute("viewBox", `0 0 ${width} ${height}`);
+
+    groups.forEach((group, i) => {
+      const barHeight = ((height - labelHeight) * group.reports) / max;
+      const rect = document.createElementNS(ns, "rect");
+      rect.setAttribute("x", i * barWidth + 1);
+      rect.setAttribute("y", height - labelHeight - barHeight);
+      rect.setAttribute("width", Math.max(1, barWidth - 2));
+      rect.setAttribute("height", barHeight);
+      const title = document.createElementNS(ns, "title");
+      title.textContent = `${group.value}: ${group.reports.toLocaleString()} new reports`;
+      rect.append(title);
+      svg.append(rect);
+    });
+
+    // Label the first and last buckets
+    for (const [i, anchor] of [[0, "start"], [groups.length - 1, "end"]]) {
+      const text = document.createElementNS(ns, "text");
+      text.setAttribute("x", anchor === "start" ? 0 : width);
+      text.setAttribute("y", height - 4);
+      text.setAttribute("text-anchor", anchor);
+      text.textContent = groups[i].value;
+      svg.append(text);
+    }
+
+    container.append(svg);
+  }
+
+  async function showDetail(id) {
+    const report = await api("reports/" + encodeURIComponent(id));
+
+    $("#detail-title").textContent = `${report.report_type} report ${report.id}`;
+
+    const meta = $("#detail-meta");
+    meta.replaceChildren();
+    const fields = [
+      ["ID", report.id],
+      ["Type", report.report_type],
+      ["Occurrences", String(report.occurrences || 1)],
+      ["First seen", formatTime(report.first_seen || report.created_at)],
+      ["Last seen", formatTime(report.last_seen || report.created_at)],
+      ["Hash", report.hash],
+      ["User agent", report.user_agent],
+      ["User agents seen", (report.user_agents || []).join("\n")],
+    ];
+    for (const [name, value] of fields) {
+      meta.append(el("dt", name), el("dd", value));
+    }
+
+    $("#detail-data").textContent = JSON.stringify(report.data, null, 2);
+
+    const related = $("#detail-related");
+    related.replaceChildren();
+    if (report.related.length === 0) {
+      related.append(el("li", "None", { class: "muted" }));
+    }
+    for (const other of report.related) {
+      const item = el("li");
+      item.append(
+        el("a", other.id, { href: "#/reports/" + encodeURIComponent(other.id) }),
+        document.createTextNode(` (${other.occurrences || 1} occurrences, last seen ${formatTime(other.last_seen)})`)
+      );
+      related.append(item);
+    }
+  }
+
+  async function refresh() {
+    showError(null);
+    try {
+      await Promise.all([loadReports(false), loadDirectives(), loadTimeline()]);
+    } catch (err) {
+      showError(err);
+    }
+  }
+
+  // route shows the view for the current location hash: "#/reports/{id}" or the list.
+  async function route() {
+    const match = window.location.hash.match(/^#\/reports\/(.+)$/);
+    $("#list-view").hidden = Boolean(match);
+    $("#detail-view").hidden = !match;
+    showError(null);
+
+    if (match) {
+      try {
+        await showDetail(decodeURIComponent(match[1]));
+      } catch (err) {
+        showError(err);
+      }
+    }
+  }
+
+  filters.addEventListener("submit", (event) => {
+    event.preventDefault();
+    refresh();
+  });
+  filters.addEventListener("reset", () => {
+    setTimeout(refresh);
+  });
+  document.querySelectorAll("input[name=bucket]").forEach((input) => {
+    input.addEventListener("change", () => loadTimeline().catch(showError));
+  });
+  $("#more").addEventListener("click", () => loadReports(true).catch(showError));
+  $("#token-button").addEventListener("click", () => {
+    if (askToken()) {
+      refresh();
+    }
+  });
+  window.addEventListener("hashchange", route);
+
+  route();
+  loadTypes().catch(showError);
+  refresh();
+})();
@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	h := Handler()
+
+	tests := []struct {
+		path        string
+		contentType string
+		contains    string
+	}{
+		{"/", "text/html; charset=utf-8", `<script src="app.js"></script>`},
+		{"/app.js", "text/javascript; charset=utf-8", "/api/"},
+		{"/app.css", "text/css; charset=utf-8", ".panel"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, contentSecurityPolicy, w.Header().Get("Content-Security-Policy"))
+			assert.Contains(t, w.Body.String(), tt.contains)
+		})
+	}
+}
+
+func TestHandler_NotFound(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/missing.js", nil)
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
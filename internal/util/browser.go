@@ -0,0 +1,37 @@
+package util
+
+import "strings"
+
+// browserFamilies maps user agent tokens to browser families. Order matters:
+// most browsers include the tokens of the engines they are derived from, so
+// e.g. Edge must be matched before Chrome and Chrome before Safari.
+var browserFamilies = []struct {
+	token  string
+	family string
+}{
+	{"Edg/", "Edge"},
+	{"EdgiOS/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Opera", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chromium/", "Chromium"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+}
+
+// BrowserFamily returns the browser family of a user agent, e.g. "Firefox".
+// Unrecognised user agents return "Other" and an empty user agent returns "".
+func BrowserFamily(userAgent string) string {
+	if userAgent == "" {
+		return ""
+	}
+	for _, b := range browserFamilies {
+		if strings.Contains(userAgent, b.token) {
+			return b.family
+		}
+	}
+	return "Other"
+}
@@ -0,0 +1,138 @@
+package util
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// FlattenColumns returns the dot-separated JSON names of the leaf fields of
+// the struct type t, e.g. "body.blockedURL", in declaration order. Nested
+// structs are flattened; slices, maps and types with their own JSON encoding
+// are leaves. A t that is not a struct is a single leaf named "".
+func FlattenColumns(t reflect.Type) []string {
+	var columns []string
+	walkFields(reflect.New(t).Elem(), "", func(name string, _ reflect.Value) {
+		columns = append(columns, name)
+	})
+	return columns
+}
+
+// Flatten returns the values of the leaf fields of v formatted as strings, in
+// the order of FlattenColumns(reflect.TypeOf(v)). Zero times and nil values
+// are empty, and slices and maps are encoded as JSON.
+func Flatten(v interface{}) ([]string, error) {
+	var values []string
+	var err error
+	walkFields(reflect.ValueOf(v), "", func(_ string, field reflect.Value) {
+		if err != nil {
+			return
+		}
+		var s string
+		s, err = formatLeaf(field)
+		values = append(values, s)
+	})
+	return values, err
+}
+
+// walkFields calls fn with the name and value of each leaf field of v. Nil
+// struct pointers are walked as zero values so that every value of a type
+// yields the same fields.
+func walkFields(v reflect.Value, name string, fn func(name string, v reflect.Value)) {
+	if !v.IsValid() {
+		fn(name, v)
+		return
+	}
+
+	t := v.Type()
+	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct && !isLeaf(t) {
+		if v.IsNil() {
+			v = reflect.Zero(t.Elem())
+		} else {
+			v = v.Elem()
+		}
+		t = v.Type()
+	}
+	if t.Kind() != reflect.Struct || isLeaf(t) {
+		fn(name, v)
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		fieldName, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && fieldName == "" {
+			walkFields(v.Field(i), name, fn)
+			continue
+		}
+		if fieldName == "" {
+			fieldName = field.Name
+		}
+		if name != "" {
+			fieldName = name + "." + fieldName
+		}
+		walkFields(v.Field(i), fieldName, fn)
+	}
+}
+
+// isLeaf reports whether t has its own JSON or text encoding, e.g. time.Time.
+func isLeaf(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType)
+}
+
+// formatLeaf formats a leaf value as a string.
+func formatLeaf(v reflect.Value) (string, error) {
+	if !v.IsValid() {
+		return "", nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return "", nil
+		}
+	}
+	if z, ok := v.Interface().(interface{ IsZero() bool }); ok && z.IsZero() {
+		return "", nil
+	}
+
+	if isLeaf(v.Type()) {
+		if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+			text, err := m.MarshalText()
+			return string(text), err
+		}
+		data, err := json.Marshal(v.Interface())
+		return string(data), err
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		return formatLeaf(v.Elem())
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	}
+
+	data, err := json.Marshal(v.Interface())
+	return string(data), err
+}
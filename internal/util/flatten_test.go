@@ -0,0 +1,73 @@
+package util
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type flattenBody struct {
+	BlockedURL string            `json:"blockedURL,omitempty"`
+	LineNumber int               `json:"lineNumber"`
+	Ratio      float64           `json:"ratio"`
+	Tags       []string          `json:"tags,omitempty"`
+	Headers    map[string]string `json:"headers"`
+	Internal   string            `json:"-"`
+}
+
+type flattenEmbedded struct {
+	Source string `json:"source"`
+}
+
+type flattenReport struct {
+	flattenEmbedded
+	URL      string           `json:"url"`
+	Body     flattenBody      `json:"body"`
+	Extra    *flattenEmbedded `json:"extra"`
+	Seen     time.Time        `json:"seen"`
+	Raw      json.RawMessage  `json:"raw"`
+	NoTag    bool
+	internal string
+}
+
+func TestFlattenColumns(t *testing.T) {
+	assert.Equal(t, []string{
+		"url",
+		"body.blockedURL", "body.lineNumber", "body.ratio", "body.tags", "body.headers",
+		"extra.source",
+		"seen", "raw", "NoTag",
+	}, FlattenColumns(reflect.TypeOf(flattenReport{})))
+
+	// Types that are not structs are a single leaf
+	assert.Equal(t, []string{""}, FlattenColumns(reflect.TypeOf(json.RawMessage{})))
+}
+
+func TestFlatten(t *testing.T) {
+	report := flattenReport{
+		URL:   "https://example.com/",
+		Body:  flattenBody{BlockedURL: "inline", LineNumber: 12, Ratio: 0.5, Tags: []string{"a", "b"}},
+		Seen:  time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Raw:   json.RawMessage(`{"k":1}`),
+		NoTag: true,
+	}
+
+	values, err := Flatten(report)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"https://example.com/",
+		"inline", "12", "0.5", `["a","b"]`, "",
+		"",
+		"2025-01-02T03:04:05Z", `{"k":1}`, "true",
+	}, values)
+
+	report.Extra = &flattenEmbedded{Source: "app.js"}
+	report.Seen = time.Time{}
+	values, err = Flatten(report)
+	require.NoError(t, err)
+	assert.Equal(t, "app.js", values[6])
+	assert.Equal(t, "", values[7])
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ResolvePointer returns the value referenced by an RFC 6901 JSON pointer within
+// doc, which is expected to be the result of decoding JSON into an interface{}.
+// The boolean result is false when the pointer does not resolve.
+func ResolvePointer(doc interface{}, pointer string) (interface{}, bool) {
+	if pointer == "" {
+		return doc, true
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+
+	current := doc
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		switch val := current.(type) {
+		case map[string]interface{}:
+			next, ok := val[token]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(val) {
+				return nil, false
+			}
+			current = val[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
@@ -0,0 +1,35 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvePointer(t *testing.T) {
+	var doc interface{}
+	err := json.Unmarshal([]byte(`{"a":{"b":[10,{"c":"d"}]},"x/y":1,"m~n":2}`), &doc)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		pointer string
+		want    interface{}
+		ok      bool
+	}{
+		{"", doc, true},
+		{"/a/b/0", float64(10), true},
+		{"/a/b/1/c", "d", true},
+		{"/x~1y", float64(1), true},
+		{"/m~0n", float64(2), true},
+		{"/a/missing", nil, false},
+		{"/a/b/5", nil, false},
+		{"/a/b/c", nil, false},
+		{"a", nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := ResolvePointer(doc, tt.pointer)
+		assert.Equal(t, tt.ok, ok, tt.pointer)
+		assert.Equal(t, tt.want, got, tt.pointer)
+	}
+}
@@ -0,0 +1,24 @@
+package queue
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/vinsonio/security-report-collector/config"
+)
+
+// New creates a new queue based on the provided configuration.
+func New(cfg *config.Queue, queueName string) (Queue, error) {
+	switch cfg.Driver {
+	case "redis":
+		return NewRedisQueue(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB, queueName)
+	case "file":
+		return NewFileQueue(filepath.Join(cfg.File.Dir, queueName), 0)
+	case "memory":
+		log.Println("Using the in-memory queue: queued reports are lost on restart; set QUEUE_DRIVER=file to keep them")
+		return NewInMemoryQueue(), nil
+	default:
+		return nil, fmt.Errorf("unsupported queue driver: %s", cfg.Driver)
+	}
+}
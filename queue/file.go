@@ -0,0 +1,557 @@
+package queue
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vinsonio/security-report-collector/registry"
+)
+
+// DefaultSegmentSize is the size at which the file queue starts a new segment.
+const DefaultSegmentSize = 64 << 20
+
+const (
+	segmentPrefix  = "segment-"
+	segmentSuffix  = ".log"
+	checkpointFile = "checkpoint"
+	// recordHeaderSize is the size of the length and checksum preceding each record.
+	recordHeaderSize = 8
+	// maxRecordSize bounds the length read from a record header, so that a
+	// corrupt header cannot make the queue allocate gigabytes.
+	maxRecordSize = 16 << 20
+)
+
+// ErrQueueClosed is returned by the operations of a closed queue.
+var ErrQueueClosed = errors.New("queue closed")
+
+// Record operations of the file queue log.
+const (
+	opEnqueue = "enqueue"
+	opMerge   = "merge"
+)
+
+// fileRecord is a record of the file queue log: an enqueued envelope, or
+// duplicates merged into the queued envelope with the same key.
+type fileRecord struct {
+	Op       string          `json:"op"`
+	Key      string          `json:"key"`
+	Envelope json.RawMessage `json:"envelope,omitempty"`
+	Count    int             `json:"count,omitempty"`
+	LastSeen time.Time       `json:"last_seen,omitempty"`
+}
+
+// filePosition is a position in the file queue log.
+type filePosition struct {
+	Segment int   `json:"segment"`
+	Offset  int64 `json:"offset"`
+}
+
+// fileEntry is an envelope in a file queue, kept encoded so that the log can be
+// replayed without the report types being registered.
+type fileEntry struct {
+	key      string
+	data     []byte
+	count    int
+	lastSeen time.Time
+	// position is the position of the enqueue record of the envelope.
+	position filePosition
+}
+
+// FileQueue is a persistent queue backed by an append-only log on disk. The
+// log is split into segment files; a checkpoint file holds the position of the
+// oldest queued envelope, and segments before it are deleted. Every record is
+// synced to disk before Enqueue returns, so queued reports survive restarts and
+// crashes. The queued envelopes are also kept in memory.
+//
+// Dequeued envelopes stay in the log until they are acknowledged (see
+// Acknowledger), so a batch being written to the database when the process
+// stops is dequeued again after a restart.
+type FileQueue struct {
+	mutex       sync.Mutex
+	dir         string
+	segmentSize int64
+	items       []*fileEntry
+	// inflight is the number of envelopes at the front of items that were
+	// dequeued but not acknowledged yet.
+	inflight    int
+	keySet      map[string]*fileEntry
+	segment     *os.File
+	end         filePosition
+	checkpoint  filePosition
+	closed      bool
+	reportTypes *registry.Registry
+}
+
+// NewFileQueue opens the file queue in dir, creating it if needed, and replays
+// its log. A segmentSize of 0 or less uses DefaultSegmentSize.
+func NewFileQueue(dir string, segmentSize int64) (*FileQueue, error) {
+	if segmentSize <= 0 {
+		segmentSize = DefaultSegmentSize
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	q := &FileQueue{
+		dir:         dir,
+		segmentSize: segmentSize,
+		keySet:      make(map[string]*fileEntry),
+		reportTypes: registry.Default,
+	}
+	if err := q.open(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// open reads the checkpoint, replays the segments from it and opens the last
+// segment for appending.
+func (q *FileQueue) open() error {
+	segments, err := q.segments()
+	if err != nil {
+		return err
+	}
+
+	checkpoint, err := q.readCheckpoint()
+	if err != nil {
+		return err
+	}
+	if checkpoint == nil {
+		checkpoint = &filePosition{Segment: 1}
+		if len(segments) > 0 {
+			checkpoint.Segment = segments[0]
+		}
+	}
+	q.checkpoint = *checkpoint
+
+	q.end = q.checkpoint
+	if !containsSegment(segments, q.end.Segment) {
+		q.end.Offset = 0
+	}
+	for i, segment := range segments {
+		if segment < q.checkpoint.Segment {
+			continue
+		}
+		offset := int64(0)
+		if segment == q.checkpoint.Segment {
+			offset = q.checkpoint.Offset
+		}
+		last := i == len(segments)-1
+		if q.end, err = q.replay(segment, offset, last); err != nil {
+			return err
+		}
+	}
+
+	q.segment, err = os.OpenFile(q.segmentPath(q.end.Segment), os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = q.segment.Seek(q.end.Offset, io.SeekStart); err != nil {
+		q.segment.Close()
+		return err
+	}
+	q.removeSegments()
+	return nil
+}
+
+// replay applies the records of a segment from offset and returns the position
+// after the last record. A record cut short by a crash at the end of the last
+// segment is truncated; a corrupt record anywhere else is an error.
+func (q *FileQueue) replay(segment int, offset int64, last bool) (filePosition, error) {
+	path := q.segmentPath(segment)
+	file, err := os.Open(path)
+	if err != nil {
+		return filePosition{}, err
+	}
+	defer file.Close()
+
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		return filePosition{}, err
+	}
+
+	reader := bufio.NewReader(file)
+	position := filePosition{Segment: segment, Offset: offset}
+	for {
+		payload, err := readRecord(reader)
+		if err == io.EOF {
+			return position, nil
+		}
+		if err != nil {
+			if !last {
+				return filePosition{}, fmt.Errorf("%s at offset %d: %w", path, position.Offset, err)
+			}
+			log.Printf("Truncating %s at offset %d: %v", path, position.Offset, err)
+			return position, os.Truncate(path, position.Offset)
+		}
+
+		var record fileRecord
+		if err := json.Unmarshal(payload, &record); err != nil {
+			return filePosition{}, fmt.Errorf("%s at offset %d: %w", path, position.Offset, err)
+		}
+		q.apply(record, position)
+		position.Offset += int64(recordHeaderSize + len(payload))
+	}
+}
+
+// apply applies a record at a position of the log to the queued envelopes.
+func (q *FileQueue) apply(record fileRecord, position filePosition) {
+	switch record.Op {
+	case opEnqueue:
+		entry := &fileEntry{key: record.Key, data: record.Envelope, position: position}
+		q.items = append(q.items, entry)
+		q.keySet[record.Key] = entry
+	case opMerge:
+		// Merges into an envelope dequeued before the checkpoint are skipped
+		if entry, ok := q.keySet[record.Key]; ok {
+			entry.count += record.Count
+			if record.LastSeen.After(entry.lastSeen) {
+				entry.lastSeen = record.LastSeen
+			}
+		}
+	}
+}
+
+// SetRegistry sets the registry queued reports are decoded with, instead of
+// registry.Default.
+func (q *FileQueue) SetRegistry(reg *registry.Registry) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.reportTypes = reg
+}
+
+// Enqueue adds a report envelope to the queue, or merges it into the queued
+// envelope with the same dedup key.
+func (q *FileQueue) Enqueue(envelope *ReportEnvelope) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	key := envelope.DedupKey()
+	record := fileRecord{Op: opMerge, Key: key, Count: envelope.Occurrences(), LastSeen: envelope.LastSeenAt()}
+	if _, ok := q.keySet[key]; !ok {
+		data, err := MarshalEnvelope(envelope)
+		if err != nil {
+			return err
+		}
+		record = fileRecord{Op: opEnqueue, Key: key, Envelope: data}
+	}
+
+	position, err := q.append(record)
+	if err != nil {
+		return err
+	}
+	q.apply(record, position)
+	return nil
+}
+
+// append writes a record to the end of the log, syncs it and returns its position.
+func (q *FileQueue) append(record fileRecord) (filePosition, error) {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return filePosition{}, err
+	}
+
+	if q.end.Offset >= q.segmentSize {
+		if err := q.rotate(); err != nil {
+			return filePosition{}, err
+		}
+	}
+
+	buf := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload))
+	copy(buf[recordHeaderSize:], payload)
+
+	position := q.end
+	_, err = q.segment.Write(buf)
+	if err == nil {
+		err = q.segment.Sync()
+	}
+	if err != nil {
+		// Drop a partly written record, so that later records are not lost behind it
+		if truncateErr := q.segment.Truncate(position.Offset); truncateErr == nil {
+			_, _ = q.segment.Seek(position.Offset, io.SeekStart)
+		}
+		return filePosition{}, err
+	}
+	q.end.Offset += int64(len(buf))
+	return position, nil
+}
+
+// rotate starts a new segment.
+func (q *FileQueue) rotate() error {
+	next := q.end.Segment + 1
+	segment, err := os.OpenFile(q.segmentPath(next), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := q.segment.Close(); err != nil {
+		segment.Close()
+		return err
+	}
+	q.segment = segment
+	q.end = filePosition{Segment: next}
+	return nil
+}
+
+// DequeueN retrieves up to n envelopes from the queue. They are removed from
+// the log by Ack, or handed out again after Release.
+func (q *FileQueue) DequeueN(n int) ([]*ReportEnvelope, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return nil, ErrQueueClosed
+	}
+	available := len(q.items) - q.inflight
+	if available == 0 || n < 1 {
+		return []*ReportEnvelope{}, nil
+	}
+
+	count := n
+	if count > available {
+		count = available
+	}
+
+	// Duplicates enqueued from now on start a new envelope
+	entries := q.items[q.inflight : q.inflight+count]
+	q.inflight += count
+	for _, entry := range entries {
+		if q.keySet[entry.key] == entry {
+			delete(q.keySet, entry.key)
+		}
+	}
+
+	envelopes := make([]*ReportEnvelope, 0, count)
+	for _, entry := range entries {
+		envelope, err := UnmarshalEnvelopeWith(q.reportTypes, entry.data)
+		if err != nil {
+			// e.g. a report type that is no longer registered; dropped by Ack
+			log.Printf("Dropping queued report (key: %s): %v", entry.key, err)
+			continue
+		}
+
+		// Add the duplicates merged while the envelope was queued
+		if entry.count > 0 {
+			envelope.Count = envelope.Occurrences() + entry.count
+		}
+		if entry.lastSeen.After(envelope.LastSeenAt()) {
+			envelope.LastSeen = entry.lastSeen
+		}
+		envelopes = append(envelopes, envelope)
+	}
+	return envelopes, nil
+}
+
+// Ack removes the dequeued envelopes from the queue, moving the checkpoint past them.
+func (q *FileQueue) Ack() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+	if q.inflight == 0 {
+		return nil
+	}
+
+	checkpoint := q.end
+	if q.inflight < len(q.items) {
+		checkpoint = q.items[q.inflight].position
+	}
+	if err := q.writeCheckpoint(checkpoint); err != nil {
+		return err
+	}
+
+	q.items = q.items[q.inflight:]
+	q.inflight = 0
+	q.removeSegments()
+	return nil
+}
+
+// Release returns the dequeued envelopes to the queue, to be dequeued again.
+func (q *FileQueue) Release() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+	for _, entry := range q.items[:q.inflight] {
+		if _, ok := q.keySet[entry.key]; !ok {
+			q.keySet[entry.key] = entry
+		}
+	}
+	q.inflight = 0
+	return nil
+}
+
+// Size returns the number of items in the queue.
+func (q *FileQueue) Size() (int, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.items), nil
+}
+
+// Contains checks if a dedup key exists in the queue (for deduplication).
+func (q *FileQueue) Contains(key string) (bool, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	_, ok := q.keySet[key]
+	return ok, nil
+}
+
+// Close closes the queue. The queued envelopes stay on disk.
+func (q *FileQueue) Close() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	q.items = nil
+	q.inflight = 0
+	q.keySet = nil
+	return q.segment.Close()
+}
+
+// writeCheckpoint atomically replaces the checkpoint file.
+func (q *FileQueue) writeCheckpoint(position filePosition) error {
+	data, err := json.Marshal(position)
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(q.dir, checkpointFile+".tmp")
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(data); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(q.dir, checkpointFile)); err != nil {
+		return err
+	}
+
+	q.checkpoint = position
+	return nil
+}
+
+// readCheckpoint returns the position in the checkpoint file, or nil if there is none.
+func (q *FileQueue) readCheckpoint() (*filePosition, error) {
+	data, err := os.ReadFile(filepath.Join(q.dir, checkpointFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var position filePosition
+	if err := json.Unmarshal(data, &position); err != nil {
+		return nil, fmt.Errorf("invalid queue checkpoint: %w", err)
+	}
+	return &position, nil
+}
+
+// removeSegments deletes the segments before the checkpoint. Segments that
+// cannot be deleted are skipped on replay, so errors are only logged.
+func (q *FileQueue) removeSegments() {
+	segments, err := q.segments()
+	if err != nil {
+		log.Printf("failed to list queue segments: %v", err)
+		return
+	}
+	for _, segment := range segments {
+		if segment >= q.checkpoint.Segment {
+			break
+		}
+		if err := os.Remove(q.segmentPath(segment)); err != nil {
+			log.Printf("failed to remove queue segment: %v", err)
+		}
+	}
+}
+
+// segments returns the numbers of the segment files, sorted.
+func (q *FileQueue) segments() ([]int, error) {
+	files, err := os.ReadDir(q.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []int
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		segment, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix))
+		if err != nil {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	sort.Ints(segments)
+	return segments, nil
+}
+
+// containsSegment reports whether segments contains segment.
+func containsSegment(segments []int, segment int) bool {
+	i := sort.SearchInts(segments, segment)
+	return i < len(segments) && segments[i] == segment
+}
+
+func (q *FileQueue) segmentPath(segment int) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%s%010d%s", segmentPrefix, segment, segmentSuffix))
+}
+
+// readRecord reads the payload of the next record. It returns io.EOF at the
+// end of the segment and an error for a record that is cut short or corrupt.
+func readRecord(reader *bufio.Reader) ([]byte, error) {
+	header := make([]byte, recordHeaderSize)
+	n, err := io.ReadFull(reader, header)
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("short record header (%d bytes): %w", n, err)
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length > maxRecordSize {
+		return nil, fmt.Errorf("record length %d exceeds %d", length, maxRecordSize)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, fmt.Errorf("short record: %w", err)
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, errors.New("record checksum mismatch")
+	}
+	return payload, nil
+}
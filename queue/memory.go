@@ -6,26 +6,32 @@ import (
 
 // InMemoryQueue is an in-memory queue implementation (not persistent).
 type InMemoryQueue struct {
-	mutex   sync.Mutex
-	items   []*ReportEnvelope
-	hashSet map[string]bool
+	mutex  sync.Mutex
+	items  []*ReportEnvelope
+	keySet map[string]*ReportEnvelope
 }
 
 // NewInMemoryQueue creates a new in-memory queue.
 func NewInMemoryQueue() *InMemoryQueue {
 	return &InMemoryQueue{
-		items:   make([]*ReportEnvelope, 0),
-		hashSet: make(map[string]bool),
+		items:  make([]*ReportEnvelope, 0),
+		keySet: make(map[string]*ReportEnvelope),
 	}
 }
 
-// Enqueue adds a report envelope to the queue.
+// Enqueue adds a report envelope to the queue, or merges it into the queued
+// envelope with the same dedup key.
 func (q *InMemoryQueue) Enqueue(envelope *ReportEnvelope) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 
+	if queued, ok := q.keySet[envelope.DedupKey()]; ok {
+		queued.merge(envelope)
+		return nil
+	}
+
 	q.items = append(q.items, envelope)
-	q.hashSet[envelope.Hash] = true
+	q.keySet[envelope.DedupKey()] = envelope
 	return nil
 }
 
@@ -46,9 +52,9 @@ func (q *InMemoryQueue) DequeueN(n int) ([]*ReportEnvelope, error) {
 	result := make([]*ReportEnvelope, count)
 	copy(result, q.items[:count])
 
-	// Remove dequeued items and their hashes
+	// Remove dequeued items and their keys
 	for _, envelope := range result {
-		delete(q.hashSet, envelope.Hash)
+		delete(q.keySet, envelope.DedupKey())
 	}
 
 	q.items = q.items[count:]
@@ -62,11 +68,12 @@ func (q *InMemoryQueue) Size() (int, error) {
 	return len(q.items), nil
 }
 
-// Contains checks if a hash exists in the queue (for deduplication).
-func (q *InMemoryQueue) Contains(hash string) (bool, error) {
+// Contains checks if a dedup key exists in the queue (for deduplication).
+func (q *InMemoryQueue) Contains(key string) (bool, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
-	return q.hashSet[hash], nil
+	_, ok := q.keySet[key]
+	return ok, nil
 }
 
 // Close closes the queue.
@@ -74,6 +81,6 @@ func (q *InMemoryQueue) Close() error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 	q.items = nil
-	q.hashSet = nil
+	q.keySet = nil
 	return nil
 }
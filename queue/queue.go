@@ -0,0 +1,128 @@
+package queue
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// ReportEnvelope contains all data needed to persist a report to the database.
+// Envelopes with the same dedup key are merged while queued: Count and LastSeen
+// track the duplicates received after the envelope was enqueued at Timestamp.
+type ReportEnvelope struct {
+	Type      string `json:"type"`
+	UserAgent string `json:"user_agent"`
+	Hash      string `json:"hash"`
+	// Key is the dedup key of the report (see database.DedupKey); empty means Hash.
+	Key       string       `json:"key,omitempty"`
+	Report    types.Report `json:"report"`
+	Timestamp time.Time    `json:"timestamp"`
+	Count     int          `json:"count,omitempty"`
+	LastSeen  time.Time    `json:"last_seen,omitempty"`
+}
+
+// DedupKey returns the key duplicates of the envelope are merged on.
+func (e *ReportEnvelope) DedupKey() string {
+	if e.Key == "" {
+		return e.Hash
+	}
+	return e.Key
+}
+
+// Occurrences returns the number of reports the envelope stands for.
+func (e *ReportEnvelope) Occurrences() int {
+	if e.Count < 1 {
+		return 1
+	}
+	return e.Count
+}
+
+// LastSeenAt returns when the last report merged into the envelope was received.
+func (e *ReportEnvelope) LastSeenAt() time.Time {
+	if e.LastSeen.After(e.Timestamp) {
+		return e.LastSeen
+	}
+	return e.Timestamp
+}
+
+// merge adds the reports of a duplicate envelope to e.
+func (e *ReportEnvelope) merge(other *ReportEnvelope) {
+	e.Count = e.Occurrences() + other.Occurrences()
+	if last := other.LastSeenAt(); last.After(e.LastSeenAt()) {
+		e.LastSeen = last
+	}
+}
+
+// Queue is the interface for a report queue.
+type Queue interface {
+	// Enqueue adds a report envelope to the queue. An envelope whose dedup key
+	// is already queued is merged into the queued envelope.
+	Enqueue(envelope *ReportEnvelope) error
+	// DequeueN retrieves and removes up to n envelopes from the queue.
+	DequeueN(n int) ([]*ReportEnvelope, error)
+	// Size returns the approximate number of items in the queue.
+	Size() (int, error)
+	// Close closes the queue.
+	Close() error
+}
+
+// Acknowledger is implemented by queues that keep dequeued envelopes until
+// they are acknowledged, like FileQueue. Envelopes dequeued but not
+// acknowledged are dequeued again after the queue is reopened.
+type Acknowledger interface {
+	// Ack removes the envelopes dequeued since the last Ack or Release.
+	Ack() error
+	// Release returns the envelopes dequeued since the last Ack or Release to
+	// the queue, to be dequeued again.
+	Release() error
+}
+
+// MarshalEnvelope serializes a report envelope to JSON bytes.
+func MarshalEnvelope(envelope *ReportEnvelope) ([]byte, error) {
+	return json.Marshal(envelope)
+}
+
+// UnmarshalEnvelope deserializes JSON bytes to a report envelope, decoding the
+// report with registry.Default.
+func UnmarshalEnvelope(data []byte) (*ReportEnvelope, error) {
+	return UnmarshalEnvelopeWith(registry.Default, data)
+}
+
+// UnmarshalEnvelopeWith deserializes JSON bytes to a report envelope, decoding
+// the report with the given registry.
+func UnmarshalEnvelopeWith(reg *registry.Registry, data []byte) (*ReportEnvelope, error) {
+	// First unmarshal into an alias that keeps report as raw JSON
+	var alias struct {
+		Type      string          `json:"type"`
+		UserAgent string          `json:"user_agent"`
+		Hash      string          `json:"hash"`
+		Key       string          `json:"key"`
+		Report    json.RawMessage `json:"report"`
+		Timestamp time.Time       `json:"timestamp"`
+		Count     int             `json:"count"`
+		LastSeen  time.Time       `json:"last_seen"`
+	}
+
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return nil, err
+	}
+
+	// Decode the concrete report using the report type registry
+	rep, err := reg.Decode(alias.Type, alias.Report)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReportEnvelope{
+		Type:      alias.Type,
+		UserAgent: alias.UserAgent,
+		Hash:      alias.Hash,
+		Key:       alias.Key,
+		Report:    rep,
+		Timestamp: alias.Timestamp,
+		Count:     alias.Count,
+		LastSeen:  alias.LastSeen,
+	}, nil
+}
@@ -0,0 +1,393 @@
+package queue_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "github.com/vinsonio/security-report-collector/handler"
+	"github.com/vinsonio/security-report-collector/queue"
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+func TestEnvelope_RoundTrip(t *testing.T) {
+	reports := []types.Report{
+		types.CSPReport{
+			URL:        "https://example.com",
+			ReportType: "csp-violation",
+			Body:       types.CSPReportBody{DocumentURL: "https://example.com", EffectiveDirective: "script-src"},
+		},
+		types.NELReport{
+			URL:        "https://example.com/api",
+			ReportType: "network-error",
+			Body:       types.NELReportBody{Phase: "connection", Type: "tcp.timed_out", ElapsedTime: 1200},
+		},
+		types.ExpectCTReport{
+			Body: types.ExpectCTReportBody{Hostname: "example.com", Port: 443, ServedCertificateChain: []string{"pem"}},
+		},
+		types.COOPReport{
+			URL:  "https://example.com",
+			Body: types.COOPReportBody{Disposition: "reporting", EffectivePolicy: "same-origin", Type: "navigation-to-response"},
+		},
+		types.COEPReport{
+			URL:  "https://example.com",
+			Body: types.COEPReportBody{Type: "corp", BlockedURL: "https://cdn.example/a.js", Destination: "script"},
+		},
+		types.DocumentPolicyReport{
+			URL:  "https://example.com",
+			Body: types.DocumentPolicyReportBody{FeatureID: "oversized-images", Disposition: "report"},
+		},
+		types.PermissionsPolicyReport{
+			URL:  "https://example.com",
+			Body: types.PermissionsPolicyReportBody{FeatureID: "geolocation", Disposition: "enforce"},
+		},
+		types.DeprecationReport{
+			URL:  "https://example.com",
+			Body: types.DeprecationReportBody{ID: "NavigatorGetUserMedia", AnticipatedRemoval: "2025-12-01"},
+		},
+		types.InterventionReport{
+			URL:  "https://example.com",
+			Body: types.InterventionReportBody{ID: "HeavyAdIntervention", Message: "Ad was removed"},
+		},
+		types.TLSRPTReport{
+			OrganizationName: "Company-X",
+			ReportID:         "report-1",
+			Policy: types.TLSRPTPolicyResult{
+				Policy:  types.TLSRPTPolicy{PolicyType: "sts", PolicyDomain: "example.com"},
+				Summary: types.TLSRPTSummary{TotalSuccessfulSessionCount: 10},
+			},
+		},
+		types.DMARCReport{
+			ReportMetadata:  types.DMARCReportMetadata{OrgName: "example.net", ReportID: "r-1"},
+			PolicyPublished: types.DMARCPolicyPublished{Domain: "example.com", P: "none"},
+			Record: types.DMARCRecord{
+				Row:         types.DMARCRow{SourceIP: "192.0.2.1", Count: 2},
+				Identifiers: types.DMARCIdentifiers{HeaderFrom: "example.com"},
+			},
+		},
+	}
+
+	for _, report := range reports {
+		t.Run(report.Type(), func(t *testing.T) {
+			envelope := &queue.ReportEnvelope{
+				Type:      report.Type(),
+				UserAgent: "UA",
+				Hash:      "hash",
+				Report:    report,
+				Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			}
+
+			b, err := queue.MarshalEnvelope(envelope)
+			require.NoError(t, err)
+
+			decoded, err := queue.UnmarshalEnvelope(b)
+			require.NoError(t, err)
+			assert.Equal(t, envelope, decoded)
+		})
+	}
+}
+
+func TestEnvelope_RoundTripMerged(t *testing.T) {
+	envelope := &queue.ReportEnvelope{
+		Type:      "nel",
+		UserAgent: "UA",
+		Hash:      "hash",
+		Key:       "hash/1735776000",
+		Report:    types.NELReport{URL: "https://example.com"},
+		Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Count:     4,
+		LastSeen:  time.Date(2025, 1, 2, 4, 4, 5, 0, time.UTC),
+	}
+
+	b, err := queue.MarshalEnvelope(envelope)
+	require.NoError(t, err)
+
+	decoded, err := queue.UnmarshalEnvelope(b)
+	require.NoError(t, err)
+	assert.Equal(t, envelope, decoded)
+}
+
+func TestUnmarshalEnvelope_UnsupportedType(t *testing.T) {
+	_, err := queue.UnmarshalEnvelope([]byte(`{"type":"unknown","report":{}}`))
+	assert.Error(t, err)
+}
+
+func TestUnmarshalEnvelope_CustomType(t *testing.T) {
+	ct := types.CustomType{Name: "queue-test-type", Fingerprint: []string{"/id"}}
+	reg := customRegistry(t, ct)
+
+	envelope := &queue.ReportEnvelope{
+		Type:      ct.Name,
+		Hash:      "hash",
+		Report:    types.CustomReport{CustomType: ct, Data: []byte(`{"id":1}`)},
+		Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	b, err := queue.MarshalEnvelope(envelope)
+	require.NoError(t, err)
+
+	// The type is only known to its own registry
+	_, err = queue.UnmarshalEnvelope(b)
+	assert.Error(t, err)
+
+	decoded, err := queue.UnmarshalEnvelopeWith(reg, b)
+	require.NoError(t, err)
+	assert.Equal(t, ct.Name, decoded.Report.Type())
+
+	data, err := decoded.Report.JSON()
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":1}`, string(data))
+}
+
+// customRegistry returns a registry holding a custom report type.
+func customRegistry(t *testing.T, ct types.CustomType) *registry.Registry {
+	reg := registry.New()
+	require.NoError(t, reg.Register(registry.ReportType{
+		Name:    ct.Name,
+		Handler: handlerFunc(nil),
+		Decode: func(data []byte) (types.Report, error) {
+			return types.CustomReport{CustomType: ct, Data: data}, nil
+		},
+	}))
+	return reg
+}
+
+// handlerFunc adapts a function to the registry.Handler interface.
+type handlerFunc func(*http.Request) (types.Report, error)
+
+func (f handlerFunc) Handle(r *http.Request) (types.Report, error) { return f(r) }
+
+func TestInMemoryQueue_MergesDuplicates(t *testing.T) {
+	q := queue.NewInMemoryQueue()
+	first := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	report := types.NELReport{URL: "https://example.com"}
+
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", UserAgent: "UA", Hash: "a", Report: report, Timestamp: first}))
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", UserAgent: "UA", Hash: "b", Report: report, Timestamp: first}))
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", UserAgent: "UA2", Hash: "a", Report: report, Timestamp: first.Add(time.Minute)}))
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", UserAgent: "UA3", Hash: "a", Report: report, Timestamp: first.Add(time.Second), Count: 3}))
+
+	size, err := q.Size()
+	require.NoError(t, err)
+	assert.Equal(t, 2, size)
+
+	envelopes, err := q.DequeueN(10)
+	require.NoError(t, err)
+	require.Len(t, envelopes, 2)
+	assert.Equal(t, "a", envelopes[0].Hash)
+	assert.Equal(t, 5, envelopes[0].Occurrences())
+	assert.Equal(t, first, envelopes[0].Timestamp)
+	assert.Equal(t, first.Add(time.Minute), envelopes[0].LastSeenAt())
+	assert.Equal(t, 1, envelopes[1].Occurrences())
+	assert.Equal(t, first, envelopes[1].LastSeenAt())
+
+	// A report enqueued after its hash was flushed starts a new envelope
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", UserAgent: "UA", Hash: "a", Report: report, Timestamp: first}))
+	envelopes, err = q.DequeueN(10)
+	require.NoError(t, err)
+	require.Len(t, envelopes, 1)
+	assert.Equal(t, 1, envelopes[0].Occurrences())
+}
+
+func TestInMemoryQueue_MergesByDedupKey(t *testing.T) {
+	q := queue.NewInMemoryQueue()
+	first := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	report := types.NELReport{URL: "https://example.com"}
+
+	// The same hash in two dedup windows is queued twice
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", Hash: "a", Key: "a/1", Report: report, Timestamp: first}))
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", Hash: "a", Key: "a/1", Report: report, Timestamp: first}))
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", Hash: "a", Key: "a/2", Report: report, Timestamp: first.Add(time.Hour)}))
+
+	contains, err := q.Contains("a/2")
+	require.NoError(t, err)
+	assert.True(t, contains)
+
+	envelopes, err := q.DequeueN(10)
+	require.NoError(t, err)
+	require.Len(t, envelopes, 2)
+	assert.Equal(t, 2, envelopes[0].Occurrences())
+	assert.Equal(t, "a/2", envelopes[1].DedupKey())
+	assert.Equal(t, 1, envelopes[1].Occurrences())
+}
+
+func TestFileQueue_MergesDuplicates(t *testing.T) {
+	q, err := queue.NewFileQueue(t.TempDir(), 0)
+	require.NoError(t, err)
+	defer q.Close()
+	first := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	report := types.NELReport{URL: "https://example.com"}
+
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", UserAgent: "UA", Hash: "a", Report: report, Timestamp: first}))
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", UserAgent: "UA", Hash: "b", Report: report, Timestamp: first}))
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", UserAgent: "UA2", Hash: "a", Report: report, Timestamp: first.Add(time.Minute)}))
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", UserAgent: "UA3", Hash: "a", Report: report, Timestamp: first.Add(time.Second), Count: 3}))
+
+	size, err := q.Size()
+	require.NoError(t, err)
+	assert.Equal(t, 2, size)
+	contains, err := q.Contains("b")
+	require.NoError(t, err)
+	assert.True(t, contains)
+
+	envelopes, err := q.DequeueN(10)
+	require.NoError(t, err)
+	require.Len(t, envelopes, 2)
+	assert.Equal(t, "a", envelopes[0].Hash)
+	assert.Equal(t, 5, envelopes[0].Occurrences())
+	assert.Equal(t, first, envelopes[0].Timestamp)
+	assert.Equal(t, first.Add(time.Minute), envelopes[0].LastSeenAt())
+	assert.Equal(t, report, envelopes[0].Report)
+	assert.Equal(t, 1, envelopes[1].Occurrences())
+
+	contains, err = q.Contains("b")
+	require.NoError(t, err)
+	assert.False(t, contains)
+}
+
+func TestFileQueue_SetRegistry(t *testing.T) {
+	ct := types.CustomType{Name: "queue-test-type", Fingerprint: []string{"/id"}}
+	q, err := queue.NewFileQueue(t.TempDir(), 0)
+	require.NoError(t, err)
+	defer q.Close()
+	q.SetRegistry(customRegistry(t, ct))
+
+	report := types.CustomReport{CustomType: ct, Data: []byte(`{"id":1}`)}
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: ct.Name, Hash: "a", Report: report, Timestamp: time.Now()}))
+
+	envelopes, err := q.DequeueN(10)
+	require.NoError(t, err)
+	if assert.Len(t, envelopes, 1) {
+		assert.Equal(t, ct.Name, envelopes[0].Report.Type())
+	}
+}
+
+func TestFileQueue_SurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	first := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	report := types.NELReport{URL: "https://example.com"}
+
+	// Segments of a single record each, so that dequeued segments are deleted
+	q, err := queue.NewFileQueue(dir, 1)
+	require.NoError(t, err)
+	for _, hash := range []string{"a", "b", "c", "b", "d"} {
+		require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", Hash: hash, Report: report, Timestamp: first}))
+	}
+	envelopes, err := q.DequeueN(1)
+	require.NoError(t, err)
+	require.Len(t, envelopes, 1)
+	assert.Equal(t, "a", envelopes[0].Hash)
+	require.NoError(t, q.Ack())
+	require.NoError(t, q.Close())
+
+	_, err = q.Size()
+	require.NoError(t, err)
+	assert.ErrorIs(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", Hash: "e", Report: report, Timestamp: first}), queue.ErrQueueClosed)
+
+	segments, err := filepath.Glob(filepath.Join(dir, "segment-*.log"))
+	require.NoError(t, err)
+	assert.Len(t, segments, 4)
+
+	// The queued envelopes and their duplicates are replayed from the checkpoint
+	q, err = queue.NewFileQueue(dir, 1)
+	require.NoError(t, err)
+	defer q.Close()
+	size, err := q.Size()
+	require.NoError(t, err)
+	assert.Equal(t, 3, size)
+
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", Hash: "c", Report: report, Timestamp: first.Add(time.Hour)}))
+	envelopes, err = q.DequeueN(10)
+	require.NoError(t, err)
+	require.Len(t, envelopes, 3)
+	assert.Equal(t, "b", envelopes[0].Hash)
+	assert.Equal(t, 2, envelopes[0].Occurrences())
+	assert.Equal(t, "c", envelopes[1].Hash)
+	assert.Equal(t, 2, envelopes[1].Occurrences())
+	assert.Equal(t, first.Add(time.Hour), envelopes[1].LastSeenAt())
+	assert.Equal(t, "d", envelopes[2].Hash)
+	require.NoError(t, q.Ack())
+
+	segments, err = filepath.Glob(filepath.Join(dir, "segment-*.log"))
+	require.NoError(t, err)
+	assert.Len(t, segments, 1)
+}
+
+func TestFileQueue_RedeliversUnacknowledged(t *testing.T) {
+	dir := t.TempDir()
+	report := types.NELReport{URL: "https://example.com"}
+
+	q, err := queue.NewFileQueue(dir, 0)
+	require.NoError(t, err)
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", Hash: "a", Report: report, Timestamp: time.Now()}))
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", Hash: "b", Report: report, Timestamp: time.Now()}))
+
+	envelopes, err := q.DequeueN(1)
+	require.NoError(t, err)
+	require.Len(t, envelopes, 1)
+	envelopes, err = q.DequeueN(10)
+	require.NoError(t, err)
+	require.Len(t, envelopes, 1)
+	assert.Equal(t, "b", envelopes[0].Hash)
+
+	// Released envelopes are dequeued again
+	require.NoError(t, q.Release())
+	envelopes, err = q.DequeueN(10)
+	require.NoError(t, err)
+	require.Len(t, envelopes, 2)
+	assert.Equal(t, "a", envelopes[0].Hash)
+
+	// As are envelopes not acknowledged before a restart
+	require.NoError(t, q.Close())
+	q, err = queue.NewFileQueue(dir, 0)
+	require.NoError(t, err)
+	defer q.Close()
+	envelopes, err = q.DequeueN(1)
+	require.NoError(t, err)
+	require.Len(t, envelopes, 1)
+	assert.Equal(t, "a", envelopes[0].Hash)
+	require.NoError(t, q.Ack())
+
+	size, err := q.Size()
+	require.NoError(t, err)
+	assert.Equal(t, 1, size)
+}
+
+func TestFileQueue_TruncatesPartialRecord(t *testing.T) {
+	dir := t.TempDir()
+	report := types.NELReport{URL: "https://example.com"}
+
+	q, err := queue.NewFileQueue(dir, 0)
+	require.NoError(t, err)
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", Hash: "a", Report: report, Timestamp: time.Now()}))
+	require.NoError(t, q.Close())
+
+	// A crash in the middle of a write leaves part of a record behind
+	segments, err := filepath.Glob(filepath.Join(dir, "segment-*.log"))
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	f, err := os.OpenFile(segments[0], os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0, 0, 1, 0, 42})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	q, err = queue.NewFileQueue(dir, 0)
+	require.NoError(t, err)
+	require.NoError(t, q.Enqueue(&queue.ReportEnvelope{Type: "nel", Hash: "b", Report: report, Timestamp: time.Now()}))
+	require.NoError(t, q.Close())
+
+	q, err = queue.NewFileQueue(dir, 0)
+	require.NoError(t, err)
+	defer q.Close()
+	envelopes, err := q.DequeueN(10)
+	require.NoError(t, err)
+	require.Len(t, envelopes, 2)
+	assert.Equal(t, "a", envelopes[0].Hash)
+	assert.Equal(t, "b", envelopes[1].Hash)
+}
@@ -0,0 +1,162 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/vinsonio/security-report-collector/registry"
+)
+
+// enqueueScript pushes an envelope unless its dedup key is already queued, in
+// which case the duplicate is added to the count and last seen time (in Unix
+// milliseconds) of the key.
+var enqueueScript = redis.NewScript(`
+if redis.call('SADD', KEYS[2], ARGV[1]) == 1 then
+	redis.call('LPUSH', KEYS[1], ARGV[2])
+else
+	redis.call('HINCRBY', KEYS[3], ARGV[1], ARGV[3])
+	local lastSeen = tonumber(redis.call('HGET', KEYS[4], ARGV[1]) or '0')
+	if tonumber(ARGV[4]) > lastSeen then
+		redis.call('HSET', KEYS[4], ARGV[1], ARGV[4])
+	end
+end
+return 1
+`)
+
+// dequeueScript pops the oldest envelope together with the duplicates merged into it.
+var dequeueScript = redis.NewScript(`
+local item = redis.call('RPOP', KEYS[1])
+if not item then
+	return false
+end
+local envelope = cjson.decode(item)
+local key = envelope['key'] or envelope['hash']
+redis.call('SREM', KEYS[2], key)
+local count = redis.call('HGET', KEYS[3], key) or '0'
+local lastSeen = redis.call('HGET', KEYS[4], key) or ''
+redis.call('HDEL', KEYS[3], key)
+redis.call('HDEL', KEYS[4], key)
+return {item, count, lastSeen}
+`)
+
+// RedisQueue is a Redis-based queue implementation.
+type RedisQueue struct {
+	client      *redis.Client
+	queueKey    string
+	hashKey     string
+	countsKey   string
+	lastSeenKey string
+	ctx         context.Context
+	reportTypes *registry.Registry
+}
+
+// NewRedisQueue creates a new Redis queue.
+func NewRedisQueue(addr, password string, db int, queueName string) (*RedisQueue, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})
+
+	ctx := context.Background()
+
+	_, err := client.Ping(ctx).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisQueue{
+		client:      client,
+		queueKey:    "queue:" + queueName,
+		hashKey:     "queue:" + queueName + ":hashes",
+		countsKey:   "queue:" + queueName + ":counts",
+		lastSeenKey: "queue:" + queueName + ":last_seen",
+		ctx:         ctx,
+		reportTypes: registry.Default,
+	}, nil
+
+}
+
+// SetRegistry sets the registry queued reports are decoded with, instead of
+// registry.Default.
+func (q *RedisQueue) SetRegistry(reg *registry.Registry) {
+	q.reportTypes = reg
+}
+
+// Enqueue adds a report envelope to the queue, or merges it into the queued
+// envelope with the same dedup key.
+func (q *RedisQueue) Enqueue(envelope *ReportEnvelope) error {
+	data, err := MarshalEnvelope(envelope)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{q.queueKey, q.hashKey, q.countsKey, q.lastSeenKey}
+	lastSeen := envelope.LastSeenAt().UnixMilli()
+	return enqueueScript.Run(q.ctx, q.client, keys, envelope.DedupKey(), data, envelope.Occurrences(), lastSeen).Err()
+}
+
+// DequeueN retrieves and removes up to n envelopes from the queue.
+func (q *RedisQueue) DequeueN(n int) ([]*ReportEnvelope, error) {
+	var envelopes []*ReportEnvelope
+
+	keys := []string{q.queueKey, q.hashKey, q.countsKey, q.lastSeenKey}
+	for i := 0; i < n; i++ {
+		// Pop from the right (FIFO)
+		result, err := dequeueScript.Run(q.ctx, q.client, keys).Result()
+		if err == redis.Nil {
+			// Queue is empty
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		values, ok := result.([]interface{})
+		if !ok || len(values) != 3 {
+			return nil, fmt.Errorf("unexpected dequeue result: %v", result)
+		}
+		item, _ := values[0].(string)
+		count, _ := values[1].(string)
+		lastSeen, _ := values[2].(string)
+
+		envelope, err := UnmarshalEnvelopeWith(q.reportTypes, []byte(item))
+		if err != nil {
+			return nil, err
+		}
+
+		// Add the duplicates merged while the envelope was queued
+		if duplicates, err := strconv.Atoi(count); err == nil && duplicates > 0 {
+			envelope.Count = envelope.Occurrences() + duplicates
+		}
+		if ms, err := strconv.ParseInt(lastSeen, 10, 64); err == nil {
+			if t := time.UnixMilli(ms).UTC(); t.After(envelope.LastSeenAt()) {
+				envelope.LastSeen = t
+			}
+		}
+
+		envelopes = append(envelopes, envelope)
+	}
+
+	return envelopes, nil
+}
+
+// Size returns the approximate number of items in the queue.
+func (q *RedisQueue) Size() (int, error) {
+	size, err := q.client.LLen(q.ctx, q.queueKey).Result()
+	return int(size), err
+}
+
+// Contains checks if a dedup key exists in the queue (for deduplication).
+func (q *RedisQueue) Contains(key string) (bool, error) {
+	return q.client.SIsMember(q.ctx, q.hashKey, key).Result()
+}
+
+// Close closes the queue.
+func (q *RedisQueue) Close() error {
+	return q.client.Close()
+}
@@ -0,0 +1,45 @@
+package registry
+
+import "github.com/vinsonio/security-report-collector/types"
+
+// Default is the registry of the built-in report types, used by the router,
+// the service and the queue unless they are given a registry of their own.
+var Default = New()
+
+// Register adds a report type to the default registry.
+func Register(rt ReportType) error {
+	return Default.Register(rt)
+}
+
+// MustRegister adds a report type to the default registry and panics on error.
+// It is intended for registering built-in report types from init functions.
+func MustRegister(rt ReportType) {
+	if err := Default.Register(rt); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns a report type from the default registry.
+func Lookup(name string) (ReportType, bool) {
+	return Default.Lookup(name)
+}
+
+// LookupReportingType returns the report type handling a Reporting API "type" value from the default registry.
+func LookupReportingType(reportingType string) (ReportType, bool) {
+	return Default.LookupReportingType(reportingType)
+}
+
+// Handlers returns the handlers of the default registry keyed by report type.
+func Handlers() map[string]Handler {
+	return Default.Handlers()
+}
+
+// Decode decodes a report using the default registry.
+func Decode(name string, data []byte) (types.Report, error) {
+	return Default.Decode(name, data)
+}
+
+// HashData returns the hash data of a report using the default registry.
+func HashData(name string, report types.Report) (interface{}, error) {
+	return Default.HashData(name, report)
+}
@@ -0,0 +1,202 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// Handler decodes a report of a specific type from an HTTP request.
+type Handler interface {
+	Handle(r *http.Request) (types.Report, error)
+}
+
+// Decoder decodes a report from its JSON representation (see types.Report.JSON),
+// e.g. when reading it back from a queue or the database.
+type Decoder func(data []byte) (types.Report, error)
+
+// ReportType describes a report type and everything needed to receive, queue and read it.
+type ReportType struct {
+	// Name is the report type, used as the /reports/{type} path segment and stored report_type.
+	Name string
+	// ReportingTypes lists the Reporting API "type" values routed to this type (e.g. "csp-violation").
+	ReportingTypes []string
+	// Description is a human-readable description of the report type.
+	Description string
+	// Handler decodes reports from incoming requests.
+	Handler Handler
+	// Decode decodes reports from their JSON representation.
+	Decode Decoder
+	// Fingerprint, if set, replaces the reports' own HashData when hashing them.
+	Fingerprint *types.Fingerprint
+}
+
+// Registry holds the known report types.
+type Registry struct {
+	mutex     sync.RWMutex
+	types     map[string]ReportType
+	reporting map[string]string
+}
+
+// New creates an empty registry.
+func New() *Registry {
+	return &Registry{
+		types:     make(map[string]ReportType),
+		reporting: make(map[string]string),
+	}
+}
+
+// Clone returns a new registry holding the report types of r. Report types
+// registered in either registry afterwards are not seen by the other.
+func (r *Registry) Clone() *Registry {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	clone := New()
+	for name, rt := range r.types {
+		clone.types[name] = rt
+	}
+	for t, name := range r.reporting {
+		clone.reporting[t] = name
+	}
+	return clone
+}
+
+// Register adds a report type to the registry.
+func (r *Registry) Register(rt ReportType) error {
+	if rt.Name == "" {
+		return errors.New("report type name is required")
+	}
+	if rt.Handler == nil {
+		return fmt.Errorf("report type %s: handler is required", rt.Name)
+	}
+	if rt.Decode == nil {
+		return fmt.Errorf("report type %s: decoder is required", rt.Name)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.types[rt.Name]; exists {
+		return fmt.Errorf("report type %s is already registered", rt.Name)
+	}
+	for _, t := range rt.ReportingTypes {
+		if owner, exists := r.reporting[t]; exists {
+			return fmt.Errorf("reporting type %s is already registered by %s", t, owner)
+		}
+	}
+
+	r.types[rt.Name] = rt
+	for _, t := range rt.ReportingTypes {
+		r.reporting[t] = rt.Name
+	}
+	return nil
+}
+
+// Unregister removes a report type from the registry.
+func (r *Registry) Unregister(name string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	rt, ok := r.types[name]
+	if !ok {
+		return
+	}
+	for _, t := range rt.ReportingTypes {
+		delete(r.reporting, t)
+	}
+	delete(r.types, name)
+}
+
+// Lookup returns a report type by name.
+func (r *Registry) Lookup(name string) (ReportType, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	rt, ok := r.types[name]
+	return rt, ok
+}
+
+// LookupReportingType returns the report type that handles a Reporting API "type" value.
+func (r *Registry) LookupReportingType(reportingType string) (ReportType, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	name, ok := r.reporting[reportingType]
+	if !ok {
+		return ReportType{}, false
+	}
+	return r.types[name], true
+}
+
+// Types returns all registered report types sorted by name.
+func (r *Registry) Types() []ReportType {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	all := make([]ReportType, 0, len(r.types))
+	for _, rt := range r.types {
+		all = append(all, rt)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// Handlers returns the handlers of all registered report types keyed by name.
+func (r *Registry) Handlers() map[string]Handler {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	handlers := make(map[string]Handler, len(r.types))
+	for name, rt := range r.types {
+		handlers[name] = rt.Handler
+	}
+	return handlers
+}
+
+// Decode decodes a report of the named type from its JSON representation.
+func (r *Registry) Decode(name string, data []byte) (types.Report, error) {
+	rt, ok := r.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unsupported report type: %s", name)
+	}
+	return rt.Decode(data)
+}
+
+// SetFingerprint replaces the hash data of the named report type with a
+// configured fingerprint. A nil fingerprint restores the report's own HashData.
+func (r *Registry) SetFingerprint(name string, fp *types.Fingerprint) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	rt, ok := r.types[name]
+	if !ok {
+		return fmt.Errorf("unsupported report type: %s", name)
+	}
+	rt.Fingerprint = fp
+	r.types[name] = rt
+	return nil
+}
+
+// HashData returns the data used to generate the hash of a report of the named
+// type: the configured fingerprint if there is one, or the report's own HashData.
+func (r *Registry) HashData(name string, report types.Report) (interface{}, error) {
+	if rt, ok := r.Lookup(name); ok && rt.Fingerprint != nil {
+		return rt.Fingerprint.HashData(report)
+	}
+	return report.HashData()
+}
+
+// JSONDecoder returns a Decoder that unmarshals JSON into a report of type T.
+func JSONDecoder[T types.Report]() Decoder {
+	return func(data []byte) (types.Report, error) {
+		var report T
+		if err := json.Unmarshal(data, &report); err != nil {
+			return nil, err
+		}
+		return report, nil
+	}
+}
@@ -0,0 +1,145 @@
+package registry
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+type stubHandler struct{}
+
+func (stubHandler) Handle(r *http.Request) (types.Report, error) { return nil, nil }
+
+func testReportType(name string, reportingTypes ...string) ReportType {
+	return ReportType{
+		Name:           name,
+		ReportingTypes: reportingTypes,
+		Handler:        stubHandler{},
+		Decode:         JSONDecoder[types.NELReport](),
+	}
+}
+
+func TestRegistry_RegisterAndLookup(t *testing.T) {
+	reg := New()
+	require.NoError(t, reg.Register(testReportType("nel", "network-error")))
+
+	rt, ok := reg.Lookup("nel")
+	assert.True(t, ok)
+	assert.Equal(t, "nel", rt.Name)
+
+	rt, ok = reg.LookupReportingType("network-error")
+	assert.True(t, ok)
+	assert.Equal(t, "nel", rt.Name)
+
+	_, ok = reg.Lookup("missing")
+	assert.False(t, ok)
+	_, ok = reg.LookupReportingType("missing")
+	assert.False(t, ok)
+
+	assert.Contains(t, reg.Handlers(), "nel")
+}
+
+func TestRegistry_RegisterErrors(t *testing.T) {
+	reg := New()
+	require.NoError(t, reg.Register(testReportType("nel", "network-error")))
+
+	assert.Error(t, reg.Register(testReportType("nel")))
+	assert.Error(t, reg.Register(testReportType("other", "network-error")))
+	assert.Error(t, reg.Register(ReportType{Name: "", Handler: stubHandler{}, Decode: JSONDecoder[types.NELReport]()}))
+	assert.Error(t, reg.Register(ReportType{Name: "no-handler", Decode: JSONDecoder[types.NELReport]()}))
+	assert.Error(t, reg.Register(ReportType{Name: "no-decoder", Handler: stubHandler{}}))
+
+	_, ok := reg.Lookup("other")
+	assert.False(t, ok)
+}
+
+func TestRegistry_Unregister(t *testing.T) {
+	reg := New()
+	require.NoError(t, reg.Register(testReportType("nel", "network-error")))
+
+	reg.Unregister("nel")
+	reg.Unregister("nel")
+
+	_, ok := reg.Lookup("nel")
+	assert.False(t, ok)
+	_, ok = reg.LookupReportingType("network-error")
+	assert.False(t, ok)
+	assert.NoError(t, reg.Register(testReportType("nel", "network-error")))
+}
+
+func TestRegistry_Clone(t *testing.T) {
+	reg := New()
+	require.NoError(t, reg.Register(testReportType("nel", "network-error")))
+
+	clone := reg.Clone()
+	rt, ok := clone.LookupReportingType("network-error")
+	assert.True(t, ok)
+	assert.Equal(t, "nel", rt.Name)
+
+	// The registries are independent
+	require.NoError(t, clone.Register(testReportType("coep")))
+	require.NoError(t, reg.Register(testReportType("coop")))
+	clone.Unregister("nel")
+	_, ok = reg.Lookup("coep")
+	assert.False(t, ok)
+	_, ok = clone.Lookup("coop")
+	assert.False(t, ok)
+	_, ok = reg.Lookup("nel")
+	assert.True(t, ok)
+}
+
+func TestRegistry_Types(t *testing.T) {
+	reg := New()
+	require.NoError(t, reg.Register(testReportType("b")))
+	require.NoError(t, reg.Register(testReportType("a")))
+
+	all := reg.Types()
+	require.Len(t, all, 2)
+	assert.Equal(t, "a", all[0].Name)
+	assert.Equal(t, "b", all[1].Name)
+}
+
+func TestRegistry_Decode(t *testing.T) {
+	reg := New()
+	require.NoError(t, reg.Register(testReportType("nel")))
+
+	report, err := reg.Decode("nel", []byte(`{"url":"https://example.com","body":{"phase":"dns"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, types.NELReport{URL: "https://example.com", Body: types.NELReportBody{Phase: "dns"}}, report)
+
+	_, err = reg.Decode("nel", []byte(`not json`))
+	assert.Error(t, err)
+
+	_, err = reg.Decode("missing", []byte(`{}`))
+	assert.Error(t, err)
+}
+
+func TestRegistry_Fingerprint(t *testing.T) {
+	reg := New()
+	require.NoError(t, reg.Register(testReportType("nel")))
+	report := types.NELReport{URL: "https://example.com/a?b=c", Body: types.NELReportBody{Phase: "dns"}}
+
+	// Without a fingerprint the report's own hash data is used
+	data, err := reg.HashData("nel", report)
+	require.NoError(t, err)
+	expected, _ := report.HashData()
+	assert.Equal(t, expected, data)
+
+	fp, err := types.NewFingerprint([]types.FingerprintField{{Pointer: "/url", Normalize: []string{types.NormalizeStripQuery}}})
+	require.NoError(t, err)
+	require.NoError(t, reg.SetFingerprint("nel", fp))
+
+	data, err = reg.HashData("nel", report)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"/url": "https://example.com/a"}, data)
+
+	require.NoError(t, reg.SetFingerprint("nel", nil))
+	data, err = reg.HashData("nel", report)
+	require.NoError(t, err)
+	assert.Equal(t, expected, data)
+
+	assert.Error(t, reg.SetFingerprint("missing", fp))
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/vinsonio/security-report-collector/database"
+	"github.com/vinsonio/security-report-collector/internal/util"
+	"github.com/vinsonio/security-report-collector/queue"
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+// Database is the interface for database operations. The read operations are
+// only available with databases implementing database.Reader.
+type Database interface {
+	Save(reportType string, report types.Report, userAgent string, hash string) error
+	Record(occurrence database.Occurrence) error
+}
+
+// ErrNotReadable is returned by the read operations of a service whose
+// database does not implement database.Reader.
+var ErrNotReadable = errors.New("database does not support reading reports")
+
+// relatedReportsLimit is the maximum number of related reports returned by GetReport.
+const relatedReportsLimit = 20
+
+// ReportDetail is a stored report together with its decoded report and the
+// other stored reports sharing its hash.
+type ReportDetail struct {
+	database.StoredReport
+	// Report is the decoded report, or nil if its type is no longer registered.
+	Report types.Report
+	// Related lists other reports of the same issue, without their data.
+	Related []database.StoredReport
+}
+
+// issueReportsLimit is the number of reports returned by GetIssue.
+const issueReportsLimit = 20
+
+// IssueDetail is an issue together with its most recently stored reports.
+type IssueDetail struct {
+	database.Issue
+	// Reports lists the newest reports of the issue, without their data.
+	Reports []database.StoredReport
+}
+
+// ReportService is the service for handling reports.
+type ReportService struct {
+	db          Database
+	reader      database.Reader
+	q           queue.Queue
+	dedupWindow time.Duration
+	reportTypes *registry.Registry
+}
+
+// NewReportService creates a new ReportService.
+func NewReportService(db Database) *ReportService {
+	reader, _ := db.(database.Reader)
+	return &ReportService{db: db, reader: reader, reportTypes: registry.Default}
+}
+
+// Readable reports whether the database of the service implements
+// database.Reader, serving the read operations.
+func (s *ReportService) Readable() bool {
+	return s.reader != nil
+}
+
+// AttachQueue attaches a queue to the service. Saved reports are then queued
+// and written to the database in batches by a scheduler.BatchFlusher.
+func (s *ReportService) AttachQueue(q queue.Queue) {
+	s.q = q
+}
+
+// SetDedupWindow sets the dedup window of the database (see database.DedupKey),
+// so that queued reports are only merged within a window.
+func (s *ReportService) SetDedupWindow(window time.Duration) {
+	s.dedupWindow = window
+}
+
+// SetRegistry sets the registry the service hashes and decodes reports with,
+// instead of registry.Default.
+func (s *ReportService) SetRegistry(reg *registry.Registry) {
+	s.reportTypes = reg
+}
+
+// Registry returns the registry of the report types known to the service.
+func (s *ReportService) Registry() *registry.Registry {
+	return s.reportTypes
+}
+
+// SaveReport saves a report.
+func (s *ReportService) SaveReport(reportType string, report types.Report, userAgent string) error {
+	hashData, err := s.reportTypes.HashData(reportType, report)
+	if err != nil {
+		return err
+	}
+
+	data, err := util.StableMarshal(hashData)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(data)
+	hashStr := hex.EncodeToString(hash[:])
+	now := time.Now().UTC()
+
+	// If a queue is attached, enqueue for later flushing. The queue merges
+	// duplicates of a queued report within a dedup window into a single envelope.
+	if s.q != nil {
+		env := &queue.ReportEnvelope{
+			Type:      reportType,
+			UserAgent: userAgent,
+			Hash:      hashStr,
+			Report:    report,
+			Timestamp: now,
+		}
+		if s.dedupWindow > 0 {
+			env.Key = database.DedupKey(hashStr, now, s.dedupWindow)
+		}
+		return s.q.Enqueue(env)
+	}
+
+	// Persist to database directly; duplicates are counted by the database
+	return s.db.Save(reportType, report, userAgent, hashStr)
+}
+
+// ListReports returns a page of stored reports matching the filter, newest first.
+func (s *ReportService) ListReports(filter database.ReportFilter) (database.ReportPage, error) {
+	if s.reader == nil {
+		return database.ReportPage{}, ErrNotReadable
+	}
+	return s.reader.Query(filter)
+}
+
+// ExportReports calls fn with each stored report matching the filter, newest
+// first, as the reports are read from the database. The limit of the filter is ignored.
+func (s *ReportService) ExportReports(filter database.ReportFilter, fn func(database.StoredReport) error) error {
+	if s.reader == nil {
+		return ErrNotReadable
+	}
+	return s.reader.Export(filter, fn)
+}
+
+// GetReport returns the stored report with the given ID, or database.ErrReportNotFound.
+func (s *ReportService) GetReport(id string) (ReportDetail, error) {
+	if s.reader == nil {
+		return ReportDetail{}, ErrNotReadable
+	}
+
+	stored, err := s.reader.Get(id)
+	if err != nil {
+		return ReportDetail{}, err
+	}
+
+	detail := ReportDetail{StoredReport: stored, Related: []database.StoredReport{}}
+	if report, err := s.reportTypes.Decode(stored.ReportType, stored.Data); err == nil {
+		detail.Report = report
+	} else {
+		log.Printf("failed to decode report %s: %v", stored.ID, err)
+	}
+
+	// Reports of the same issue share a directive and blocked origin, while
+	// reports with the same hash are stored as one row per dedup window.
+	// Reports stored before issues existed only match on their hash.
+	related := database.ReportFilter{Issue: stored.IssueID, Limit: relatedReportsLimit + 1}
+	if stored.IssueID == "" {
+		related = database.ReportFilter{Hash: stored.Hash, Limit: relatedReportsLimit + 1}
+	}
+	page, err := s.reader.Query(related)
+	if err != nil {
+		return ReportDetail{}, err
+	}
+	for _, related := range page.Reports {
+		if related.ID == stored.ID || len(detail.Related) == relatedReportsLimit {
+			continue
+		}
+		related.Data = nil
+		detail.Related = append(detail.Related, related)
+	}
+
+	return detail, nil
+}
+
+// Stats returns report counts grouped by the dimension of the query.
+func (s *ReportService) Stats(query database.StatsQuery) ([]database.StatsGroup, error) {
+	if s.reader == nil {
+		return nil, ErrNotReadable
+	}
+	return s.reader.Stats(query)
+}
+
+// ListIssues returns a page of issues matching the filter, newest first.
+func (s *ReportService) ListIssues(filter database.IssueFilter) (database.IssuePage, error) {
+	if s.reader == nil {
+		return database.IssuePage{}, ErrNotReadable
+	}
+	return s.reader.Issues(filter)
+}
+
+// GetIssue returns the issue with the given ID, or database.ErrIssueNotFound.
+func (s *ReportService) GetIssue(id string) (IssueDetail, error) {
+	if s.reader == nil {
+		return IssueDetail{}, ErrNotReadable
+	}
+
+	issue, err := s.reader.GetIssue(id)
+	if err != nil {
+		return IssueDetail{}, err
+	}
+
+	page, err := s.reader.Query(database.ReportFilter{Issue: issue.ID, Limit: issueReportsLimit})
+	if err != nil {
+		return IssueDetail{}, err
+	}
+
+	detail := IssueDetail{Issue: issue, Reports: page.Reports}
+	for i := range detail.Reports {
+		detail.Reports[i].Data = nil
+	}
+	return detail, nil
+}
+
+// UpdateIssue changes the triage state of an issue, or returns database.ErrIssueNotFound.
+func (s *ReportService) UpdateIssue(id string, update database.IssueUpdate) (database.Issue, error) {
+	if s.reader == nil {
+		return database.Issue{}, ErrNotReadable
+	}
+	return s.reader.UpdateIssue(id, update)
+}
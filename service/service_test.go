@@ -0,0 +1,198 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vinsonio/security-report-collector/database"
+	databasetesting "github.com/vinsonio/security-report-collector/internal/testing"
+	"github.com/vinsonio/security-report-collector/queue"
+	"github.com/vinsonio/security-report-collector/registry"
+	"github.com/vinsonio/security-report-collector/types"
+)
+
+func TestSaveReport_QueueMergesDuplicates(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	service := NewReportService(store)
+	q := queue.NewInMemoryQueue()
+	service.AttachQueue(q)
+
+	report := types.CSPReport{Body: types.CSPReportBody{DocumentURL: "https://example.com"}}
+	assert.NoError(t, service.SaveReport("csp", report, "UA"))
+	assert.NoError(t, service.SaveReport("csp", report, "UA2"))
+
+	envelopes, err := q.DequeueN(10)
+	assert.NoError(t, err)
+	if assert.Len(t, envelopes, 1) {
+		assert.Equal(t, 2, envelopes[0].Occurrences())
+		assert.Equal(t, "UA", envelopes[0].UserAgent)
+	}
+	store.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSaveReport_SavesWithoutQueue(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	service := NewReportService(store)
+
+	report := types.CSPReport{Body: types.CSPReportBody{DocumentURL: "https://example.com"}}
+
+	// Expect only Save
+	store.On("Save", "csp", mock.AnythingOfType("types.CSPReport"), "UA", mock.AnythingOfType("string")).Return(nil)
+
+	err := service.SaveReport("csp", report, "UA")
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestReport_JSONAndHashData(t *testing.T) {
+	report := types.CSPReport{
+		URL:        "https://example.com",
+		ReportType: "csp-violation",
+		Body: types.CSPReportBody{
+			DocumentURL:        "https://example.com",
+			EffectiveDirective: "script-src",
+			BlockedURL:         "https://cdn.example.com/script.js",
+			SourceFile:         "https://example.com/app.js",
+			LineNumber:         10,
+			ColumnNumber:       20,
+		},
+	}
+
+	b, err := report.JSON()
+	assert.NoError(t, err)
+	assert.True(t, json.Valid(b))
+
+	h, err := report.HashData()
+	assert.NoError(t, err)
+	m, ok := h.(types.CSPReportHashData)
+	assert.True(t, ok)
+	assert.Equal(t, "https://example.com", m.DocumentURL)
+	assert.Equal(t, "script-src", m.EffectiveDirective)
+	assert.Equal(t, "https://cdn.example.com/script.js", m.BlockedURL)
+	assert.Equal(t, "https://example.com/app.js", m.SourceFile)
+	assert.Equal(t, 10, m.LineNumber)
+	assert.Equal(t, 20, m.ColumnNumber)
+}
+
+func TestGetReport(t *testing.T) {
+	require.NoError(t, registry.Register(registry.ReportType{
+		Name:    "service-test",
+		Handler: nopHandler{},
+		Decode:  registry.JSONDecoder[types.CSPReport](),
+	}))
+	t.Cleanup(func() { registry.Default.Unregister("service-test") })
+
+	t.Setenv("DB_CONNECTION", "sqlite")
+	t.Setenv("DB_DATABASE", filepath.Join(t.TempDir(), "service.db"))
+	store := databasetesting.GetDBForTest(t)
+	service := NewReportService(store)
+
+	// Violations of the same directive and blocked origin on two pages have
+	// different hashes, but belong to the same issue
+	report := types.CSPReport{URL: "https://example.com/a", Body: types.CSPReportBody{EffectiveDirective: "script-src", BlockedURL: "https://cdn.example.net/a.js"}}
+	related := types.CSPReport{URL: "https://example.com/b", Body: types.CSPReportBody{EffectiveDirective: "script-src", BlockedURL: "https://cdn.example.net/b.js"}}
+	other := types.CSPReport{URL: "https://example.com/a", Body: types.CSPReportBody{EffectiveDirective: "img-src", BlockedURL: "https://cdn.example.net/a.png"}}
+	for _, r := range []types.CSPReport{related, other, report} {
+		require.NoError(t, service.SaveReport("service-test", r, "UA"))
+	}
+
+	page, err := store.Query(database.ReportFilter{Directive: "script-src"})
+	require.NoError(t, err)
+	require.Len(t, page.Reports, 2)
+	stored, relatedStored := page.Reports[0], page.Reports[1]
+	if strings.Contains(string(stored.Data), related.URL) {
+		stored, relatedStored = relatedStored, stored
+	}
+	require.NotEqual(t, stored.Hash, relatedStored.Hash)
+
+	detail, err := service.GetReport(stored.ID)
+	require.NoError(t, err)
+	assert.Equal(t, stored.ID, detail.ID)
+	assert.Equal(t, report, detail.Report)
+	if assert.Len(t, detail.Related, 1) {
+		assert.Equal(t, relatedStored.ID, detail.Related[0].ID)
+		assert.Nil(t, detail.Related[0].Data)
+	}
+}
+
+func TestGetReport_UnregisteredType(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	service := NewReportService(store)
+
+	stored := database.StoredReport{ID: "01J00000000000000000000001", ReportType: "removed-type", Data: json.RawMessage(`{}`), Hash: "h"}
+	store.On("Get", stored.ID).Return(stored, nil)
+	store.On("Query", mock.Anything).Return(database.ReportPage{Reports: []database.StoredReport{stored}}, nil)
+
+	detail, err := service.GetReport(stored.ID)
+	require.NoError(t, err)
+	assert.Nil(t, detail.Report)
+	assert.Empty(t, detail.Related)
+}
+
+func TestGetReport_NotFound(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	service := NewReportService(store)
+	store.On("Get", "missing").Return(database.StoredReport{}, database.ErrReportNotFound)
+
+	_, err := service.GetReport("missing")
+	assert.ErrorIs(t, err, database.ErrReportNotFound)
+	store.AssertNotCalled(t, "Query", mock.Anything)
+}
+
+func TestReadOperations_WriteOnlyDB(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	service := NewReportService(struct{ Database }{store})
+	assert.False(t, service.Readable())
+
+	_, err := service.ListReports(database.ReportFilter{})
+	assert.ErrorIs(t, err, ErrNotReadable)
+	_, err = service.GetIssue("01J00000000000000000000000")
+	assert.ErrorIs(t, err, ErrNotReadable)
+	store.AssertNotCalled(t, "Query", mock.Anything)
+}
+
+type nopHandler struct{}
+
+func (nopHandler) Handle(r *http.Request) (types.Report, error) { return nil, nil }
+
+func TestGetIssue(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	service := NewReportService(store)
+
+	issue := database.Issue{ID: "01J00000000000000000000003", ReportType: "csp", Status: database.IssueOpen}
+	report := database.StoredReport{ID: "01J00000000000000000000001", ReportType: "csp", Data: json.RawMessage(`{}`), IssueID: issue.ID}
+	store.On("GetIssue", issue.ID).Return(issue, nil)
+	store.On("Query", database.ReportFilter{Issue: issue.ID, Limit: issueReportsLimit}).Return(database.ReportPage{Reports: []database.StoredReport{report}}, nil)
+
+	detail, err := service.GetIssue(issue.ID)
+	require.NoError(t, err)
+	assert.Equal(t, issue, detail.Issue)
+	if assert.Len(t, detail.Reports, 1) {
+		assert.Equal(t, report.ID, detail.Reports[0].ID)
+		assert.Nil(t, detail.Reports[0].Data)
+	}
+}
+
+func TestSaveReport_QueueDedupWindow(t *testing.T) {
+	store := new(databasetesting.MockDB)
+	service := NewReportService(store)
+	service.SetDedupWindow(24 * time.Hour)
+	q := queue.NewInMemoryQueue()
+	service.AttachQueue(q)
+
+	report := types.CSPReport{Body: types.CSPReportBody{DocumentURL: "https://example.com"}}
+	assert.NoError(t, service.SaveReport("csp", report, "UA"))
+
+	envelopes, err := q.DequeueN(10)
+	require.NoError(t, err)
+	require.Len(t, envelopes, 1)
+	env := envelopes[0]
+	assert.Equal(t, database.DedupKey(env.Hash, env.Timestamp, 24*time.Hour), env.Key)
+}
@@ -0,0 +1,54 @@
+package types
+
+import "encoding/json"
+
+// COEPReport is a wrapper for a Cross-Origin-Embedder-Policy violation report body.
+type COEPReport struct {
+	URL        string         `json:"url,omitempty"`
+	ReportType string         `json:"type,omitempty"`
+	Body       COEPReportBody `json:"body"`
+}
+
+// Type returns the type of the report.
+func (r COEPReport) Type() string {
+	return "coep"
+}
+
+// JSON returns the JSON representation of the report.
+func (r COEPReport) JSON() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// HashData returns the data used to generate the report's hash.
+func (r COEPReport) HashData() (interface{}, error) {
+	return COEPReportHashData{
+		URL:           r.URL,
+		Disposition:   r.Body.Disposition,
+		ViolationType: r.Body.Type,
+		BlockedURL:    r.Body.BlockedURL,
+		Destination:   r.Body.Destination,
+	}, nil
+}
+
+// Summary returns the values of the report used for statistics.
+func (r COEPReport) Summary() Summary {
+	return Summary{BlockedURL: r.Body.BlockedURL, DocumentURL: r.URL}
+}
+
+// COEPReportHashData defines the structure of the data used to generate the report hash.
+// A violation is identified by the embedding document and the resource it failed to load.
+type COEPReportHashData struct {
+	URL           string `json:"url,omitempty"`
+	Disposition   string `json:"disposition,omitempty"`
+	ViolationType string `json:"type,omitempty"`
+	BlockedURL    string `json:"blockedURL,omitempty"`
+	Destination   string `json:"destination,omitempty"`
+}
+
+// COEPReportBody defines the structure of a COEP report body.
+type COEPReportBody struct {
+	Type        string `json:"type,omitempty"`
+	BlockedURL  string `json:"blockedURL,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	Disposition string `json:"disposition,omitempty"`
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCOEPReport_Type(t *testing.T) {
+	report := COEPReport{}
+	assert.Equal(t, "coep", report.Type())
+}
+
+func TestCOEPReport_JSONAndHashData(t *testing.T) {
+	report := COEPReport{
+		URL:        "https://example.com/",
+		ReportType: "coep",
+		Body: COEPReportBody{
+			Type:        "corp",
+			BlockedURL:  "https://cdn.example/lib.js",
+			Destination: "script",
+			Disposition: "reporting",
+		},
+	}
+
+	b, err := report.JSON()
+	assert.NoError(t, err)
+	var unmarshaled COEPReport
+	assert.NoError(t, json.Unmarshal(b, &unmarshaled))
+	assert.Equal(t, report, unmarshaled)
+
+	hashData, err := report.HashData()
+	assert.NoError(t, err)
+	assert.Equal(t, COEPReportHashData{
+		URL:           "https://example.com/",
+		Disposition:   "reporting",
+		ViolationType: "corp",
+		BlockedURL:    "https://cdn.example/lib.js",
+		Destination:   "script",
+	}, hashData)
+}
+
+func TestCOEPReport_Summary(t *testing.T) {
+	report := COEPReport{
+		URL:  "https://example.com/",
+		Body: COEPReportBody{Type: "corp", BlockedURL: "https://cdn.example/a.js"},
+	}
+	assert.Equal(t, Summary{BlockedURL: "https://cdn.example/a.js", DocumentURL: "https://example.com/"}, report.Summary())
+}
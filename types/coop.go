@@ -0,0 +1,71 @@
+package types
+
+import "encoding/json"
+
+// COOPReport is a wrapper for a Cross-Origin-Opener-Policy violation report body.
+type COOPReport struct {
+	URL        string         `json:"url,omitempty"`
+	ReportType string         `json:"type,omitempty"`
+	Body       COOPReportBody `json:"body"`
+}
+
+// Type returns the type of the report.
+func (r COOPReport) Type() string {
+	return "coop"
+}
+
+// JSON returns the JSON representation of the report.
+func (r COOPReport) JSON() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// HashData returns the data used to generate the report's hash.
+func (r COOPReport) HashData() (interface{}, error) {
+	return COOPReportHashData{
+		URL:                 r.URL,
+		Disposition:         r.Body.Disposition,
+		EffectivePolicy:     r.Body.EffectivePolicy,
+		ViolationType:       r.Body.Type,
+		PreviousResponseURL: r.Body.PreviousResponseURL,
+		NextResponseURL:     r.Body.NextResponseURL,
+		OtherDocumentURL:    r.Body.OtherDocumentURL,
+		Property:            r.Body.Property,
+	}, nil
+}
+
+// Summary returns the values of the report used for statistics.
+func (r COOPReport) Summary() Summary {
+	return Summary{DocumentURL: r.URL}
+}
+
+// COOPReportHashData defines the structure of the data used to generate the report hash.
+// A violation is identified by the reporting document, the policy in effect, the kind of
+// violation and the other side of the navigation or access.
+type COOPReportHashData struct {
+	URL                 string `json:"url,omitempty"`
+	Disposition         string `json:"disposition,omitempty"`
+	EffectivePolicy     string `json:"effectivePolicy,omitempty"`
+	ViolationType       string `json:"type,omitempty"`
+	PreviousResponseURL string `json:"previousResponseURL,omitempty"`
+	NextResponseURL     string `json:"nextResponseURL,omitempty"`
+	OtherDocumentURL    string `json:"otherDocumentURL,omitempty"`
+	Property            string `json:"property,omitempty"`
+}
+
+// COOPReportBody defines the structure of a COOP report body.
+type COOPReportBody struct {
+	Disposition         string `json:"disposition,omitempty"`
+	EffectivePolicy     string `json:"effectivePolicy,omitempty"`
+	Type                string `json:"type,omitempty"`
+	PreviousResponseURL string `json:"previousResponseURL,omitempty"`
+	NextResponseURL     string `json:"nextResponseURL,omitempty"`
+	Referrer            string `json:"referrer,omitempty"`
+	Property            string `json:"property,omitempty"`
+	OpenerURL           string `json:"openerURL,omitempty"`
+	OpeneeURL           string `json:"openeeURL,omitempty"`
+	OtherDocumentURL    string `json:"otherDocumentURL,omitempty"`
+	InitialPopupURL     string `json:"initialPopupURL,omitempty"`
+	SourceFile          string `json:"sourceFile,omitempty"`
+	LineNumber          int    `json:"lineNumber,omitempty"`
+	ColumnNumber        int    `json:"columnNumber,omitempty"`
+}
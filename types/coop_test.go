@@ -0,0 +1,43 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCOOPReport_Type(t *testing.T) {
+	report := COOPReport{}
+	assert.Equal(t, "coop", report.Type())
+}
+
+func TestCOOPReport_JSONAndHashData(t *testing.T) {
+	report := COOPReport{
+		URL:        "https://example.com/",
+		ReportType: "coop",
+		Body: COOPReportBody{
+			Disposition:         "reporting",
+			EffectivePolicy:     "same-origin",
+			Type:                "navigation-to-response",
+			PreviousResponseURL: "https://other.example/",
+			Referrer:            "https://other.example/",
+		},
+	}
+
+	b, err := report.JSON()
+	assert.NoError(t, err)
+	var unmarshaled COOPReport
+	assert.NoError(t, json.Unmarshal(b, &unmarshaled))
+	assert.Equal(t, report, unmarshaled)
+
+	hashData, err := report.HashData()
+	assert.NoError(t, err)
+	assert.Equal(t, COOPReportHashData{
+		URL:                 "https://example.com/",
+		Disposition:         "reporting",
+		EffectivePolicy:     "same-origin",
+		ViolationType:       "navigation-to-response",
+		PreviousResponseURL: "https://other.example/",
+	}, hashData)
+}
@@ -0,0 +1,57 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/vinsonio/security-report-collector/internal/util"
+)
+
+// CustomType describes a user-defined report type declared in configuration.
+type CustomType struct {
+	// Name is the report type.
+	Name string
+	// Fingerprint lists the JSON pointers whose values make up the report hash.
+	Fingerprint []string
+}
+
+// CustomReport is a report of a user-defined type. The report is stored as received.
+type CustomReport struct {
+	CustomType
+	Data json.RawMessage
+}
+
+// Type returns the type of the report.
+func (r CustomReport) Type() string {
+	return r.Name
+}
+
+// JSON returns the JSON representation of the report.
+func (r CustomReport) JSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, r.Data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalJSON encodes the report as received, without the type declaration.
+func (r CustomReport) MarshalJSON() ([]byte, error) {
+	return r.JSON()
+}
+
+// HashData returns the data used to generate the report's hash: the values at the
+// type's fingerprint pointers, keyed by pointer. Missing values hash as null.
+func (r CustomReport) HashData() (interface{}, error) {
+	var doc interface{}
+	if err := json.Unmarshal(r.Data, &doc); err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]interface{}, len(r.Fingerprint))
+	for _, ptr := range r.Fingerprint {
+		value, _ := util.ResolvePointer(doc, ptr)
+		data[ptr] = value
+	}
+	return data, nil
+}
@@ -0,0 +1,33 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomReport(t *testing.T) {
+	report := CustomReport{
+		CustomType: CustomType{Name: "login-anomaly", Fingerprint: []string{"/app", "/event/kind", "/missing"}},
+		Data:       json.RawMessage(`{"app": "billing", "event": {"kind": "impossible-travel", "ip": "192.0.2.1"}}`),
+	}
+
+	assert.Equal(t, "login-anomaly", report.Type())
+
+	b, err := report.JSON()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"app":"billing","event":{"kind":"impossible-travel","ip":"192.0.2.1"}}`, string(b))
+
+	marshaled, err := json.Marshal(report)
+	assert.NoError(t, err)
+	assert.Equal(t, b, marshaled)
+
+	hashData, err := report.HashData()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"/app":        "billing",
+		"/event/kind": "impossible-travel",
+		"/missing":    nil,
+	}, hashData)
+}
@@ -0,0 +1,56 @@
+package types
+
+import "encoding/json"
+
+// DeprecationReport is a wrapper for a deprecation report body, sent when a page
+// uses a deprecated browser API.
+type DeprecationReport struct {
+	URL        string                `json:"url,omitempty"`
+	ReportType string                `json:"type,omitempty"`
+	Body       DeprecationReportBody `json:"body"`
+}
+
+// Type returns the type of the report.
+func (r DeprecationReport) Type() string {
+	return "deprecation"
+}
+
+// JSON returns the JSON representation of the report.
+func (r DeprecationReport) JSON() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// HashData returns the data used to generate the report's hash.
+func (r DeprecationReport) HashData() (interface{}, error) {
+	return DeprecationReportHashData{
+		URL:          r.URL,
+		ID:           r.Body.ID,
+		SourceFile:   r.Body.SourceFile,
+		LineNumber:   r.Body.LineNumber,
+		ColumnNumber: r.Body.ColumnNumber,
+	}, nil
+}
+
+// Summary returns the values of the report used for statistics.
+func (r DeprecationReport) Summary() Summary {
+	return Summary{Directive: r.Body.ID, DocumentURL: r.URL}
+}
+
+// DeprecationReportHashData defines the structure of the data used to generate the report hash.
+type DeprecationReportHashData struct {
+	URL          string `json:"url,omitempty"`
+	ID           string `json:"id,omitempty"`
+	SourceFile   string `json:"sourceFile,omitempty"`
+	LineNumber   int    `json:"lineNumber,omitempty"`
+	ColumnNumber int    `json:"columnNumber,omitempty"`
+}
+
+// DeprecationReportBody defines the structure of a deprecation report body.
+type DeprecationReportBody struct {
+	ID                 string `json:"id,omitempty"`
+	AnticipatedRemoval string `json:"anticipatedRemoval,omitempty"`
+	Message            string `json:"message,omitempty"`
+	SourceFile         string `json:"sourceFile,omitempty"`
+	LineNumber         int    `json:"lineNumber,omitempty"`
+	ColumnNumber       int    `json:"columnNumber,omitempty"`
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecationReport_Type(t *testing.T) {
+	report := DeprecationReport{}
+	assert.Equal(t, "deprecation", report.Type())
+}
+
+func TestDeprecationReport_JSONAndHashData(t *testing.T) {
+	report := DeprecationReport{
+		URL:        "https://example.com/",
+		ReportType: "deprecation",
+		Body: DeprecationReportBody{
+			ID:                 "websql",
+			AnticipatedRemoval: "2025-01-01",
+			Message:            "WebSQL is deprecated",
+			SourceFile:         "https://example.com/db.js",
+			LineNumber:         10,
+		},
+	}
+
+	b, err := report.JSON()
+	assert.NoError(t, err)
+	var unmarshaled DeprecationReport
+	assert.NoError(t, json.Unmarshal(b, &unmarshaled))
+	assert.Equal(t, report, unmarshaled)
+
+	hashData, err := report.HashData()
+	assert.NoError(t, err)
+	assert.Equal(t, DeprecationReportHashData{
+		URL:        "https://example.com/",
+		ID:         "websql",
+		SourceFile: "https://example.com/db.js",
+		LineNumber: 10,
+	}, hashData)
+}
+
+func TestDeprecationReport_Summary(t *testing.T) {
+	report := DeprecationReport{
+		URL:  "https://example.com/page",
+		Body: DeprecationReportBody{ID: "NavigatorGetUserMedia"},
+	}
+	assert.Equal(t, Summary{Directive: "NavigatorGetUserMedia", DocumentURL: "https://example.com/page"}, report.Summary())
+}
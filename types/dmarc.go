@@ -0,0 +1,188 @@
+package types
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+)
+
+// DMARCReport is a single record of a DMARC aggregate (RUA) report, together
+// with the report metadata and the published policy it was evaluated against.
+// Aggregate reports arrive as XML; JSON returns the canonical representation
+// that is stored.
+type DMARCReport struct {
+	ReportMetadata  DMARCReportMetadata  `json:"report_metadata"`
+	PolicyPublished DMARCPolicyPublished `json:"policy_published"`
+	Record          DMARCRecord          `json:"record"`
+}
+
+// Type returns the type of the report.
+func (r DMARCReport) Type() string {
+	return "dmarc"
+}
+
+// JSON returns the JSON representation of the report.
+func (r DMARCReport) JSON() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// HashData returns the data used to generate the report's hash.
+func (r DMARCReport) HashData() (interface{}, error) {
+	return DMARCReportHashData{
+		OrgName:      r.ReportMetadata.OrgName,
+		ReportID:     r.ReportMetadata.ReportID,
+		SourceIP:     r.Record.Row.SourceIP,
+		HeaderFrom:   r.Record.Identifiers.HeaderFrom,
+		EnvelopeFrom: r.Record.Identifiers.EnvelopeFrom,
+		Disposition:  r.Record.Row.PolicyEvaluated.Disposition,
+		DKIM:         r.Record.Row.PolicyEvaluated.DKIM,
+		SPF:          r.Record.Row.PolicyEvaluated.SPF,
+	}, nil
+}
+
+// DMARCReportHashData defines the structure of the data used to generate the report hash.
+// A record is identified by the report it belongs to and its source and identifiers,
+// so re-delivered reports are deduplicated while each record keeps its own row.
+type DMARCReportHashData struct {
+	OrgName      string `json:"orgName,omitempty"`
+	ReportID     string `json:"reportId,omitempty"`
+	SourceIP     string `json:"sourceIp,omitempty"`
+	HeaderFrom   string `json:"headerFrom,omitempty"`
+	EnvelopeFrom string `json:"envelopeFrom,omitempty"`
+	Disposition  string `json:"disposition,omitempty"`
+	DKIM         string `json:"dkim,omitempty"`
+	SPF          string `json:"spf,omitempty"`
+}
+
+// DMARCAggregateReport defines the structure of a DMARC aggregate report (RFC 7489, Appendix C).
+type DMARCAggregateReport struct {
+	XMLName         xml.Name             `xml:"feedback"`
+	Version         string               `xml:"version"`
+	ReportMetadata  DMARCReportMetadata  `xml:"report_metadata"`
+	PolicyPublished DMARCPolicyPublished `xml:"policy_published"`
+	Records         []DMARCRecord        `xml:"record"`
+}
+
+// DMARCReportMetadata defines the metadata of a DMARC aggregate report.
+type DMARCReportMetadata struct {
+	OrgName          string         `xml:"org_name" json:"org_name"`
+	Email            string         `xml:"email" json:"email,omitempty"`
+	ExtraContactInfo string         `xml:"extra_contact_info" json:"extra_contact_info,omitempty"`
+	ReportID         string         `xml:"report_id" json:"report_id"`
+	DateRange        DMARCDateRange `xml:"date_range" json:"date_range"`
+	Errors           []string       `xml:"error" json:"error,omitempty"`
+}
+
+// DMARCDateRange defines the reporting period as Unix timestamps.
+type DMARCDateRange struct {
+	Begin int64 `xml:"begin" json:"begin"`
+	End   int64 `xml:"end" json:"end"`
+}
+
+// DMARCPolicyPublished defines the DMARC policy published for the domain.
+type DMARCPolicyPublished struct {
+	Domain string `xml:"domain" json:"domain"`
+	ADKIM  string `xml:"adkim" json:"adkim,omitempty"`
+	ASPF   string `xml:"aspf" json:"aspf,omitempty"`
+	P      string `xml:"p" json:"p"`
+	SP     string `xml:"sp" json:"sp,omitempty"`
+	Pct    int    `xml:"pct" json:"pct,omitempty"`
+	FO     string `xml:"fo" json:"fo,omitempty"`
+}
+
+// DMARCRecord defines a single row of a DMARC aggregate report.
+type DMARCRecord struct {
+	Row         DMARCRow         `xml:"row" json:"row"`
+	Identifiers DMARCIdentifiers `xml:"identifiers" json:"identifiers"`
+	AuthResults DMARCAuthResults `xml:"auth_results" json:"auth_results"`
+}
+
+// DMARCRow defines the source and the evaluated policy of a record.
+type DMARCRow struct {
+	SourceIP        string               `xml:"source_ip" json:"source_ip"`
+	Count           int64                `xml:"count" json:"count"`
+	PolicyEvaluated DMARCPolicyEvaluated `xml:"policy_evaluated" json:"policy_evaluated"`
+}
+
+// DMARCPolicyEvaluated defines the result of applying the published policy.
+type DMARCPolicyEvaluated struct {
+	Disposition string                  `xml:"disposition" json:"disposition"`
+	DKIM        string                  `xml:"dkim" json:"dkim"`
+	SPF         string                  `xml:"spf" json:"spf"`
+	Reasons     []DMARCPolicyOverridden `xml:"reason" json:"reason,omitempty"`
+}
+
+// DMARCPolicyOverridden defines why the applied policy differs from the published one.
+type DMARCPolicyOverridden struct {
+	Type    string `xml:"type" json:"type"`
+	Comment string `xml:"comment" json:"comment,omitempty"`
+}
+
+// DMARCIdentifiers defines the identifiers of the messages in a record.
+type DMARCIdentifiers struct {
+	EnvelopeTo   string `xml:"envelope_to" json:"envelope_to,omitempty"`
+	EnvelopeFrom string `xml:"envelope_from" json:"envelope_from,omitempty"`
+	HeaderFrom   string `xml:"header_from" json:"header_from"`
+}
+
+// DMARCAuthResults defines the DKIM and SPF results of a record.
+type DMARCAuthResults struct {
+	DKIM []DMARCDKIMResult `xml:"dkim" json:"dkim,omitempty"`
+	SPF  []DMARCSPFResult  `xml:"spf" json:"spf,omitempty"`
+}
+
+// DMARCDKIMResult defines a single DKIM authentication result.
+type DMARCDKIMResult struct {
+	Domain      string `xml:"domain" json:"domain"`
+	Selector    string `xml:"selector" json:"selector,omitempty"`
+	Result      string `xml:"result" json:"result"`
+	HumanResult string `xml:"human_result" json:"human_result,omitempty"`
+}
+
+// DMARCSPFResult defines a single SPF authentication result.
+type DMARCSPFResult struct {
+	Domain string `xml:"domain" json:"domain"`
+	Scope  string `xml:"scope" json:"scope,omitempty"`
+	Result string `xml:"result" json:"result"`
+}
+
+// Validate checks the report for the fields required to store its records.
+func (r DMARCAggregateReport) Validate() error {
+	if r.ReportMetadata.OrgName == "" {
+		return errors.New("dmarc: missing report_metadata org_name")
+	}
+	if r.ReportMetadata.ReportID == "" {
+		return errors.New("dmarc: missing report_metadata report_id")
+	}
+	if r.PolicyPublished.Domain == "" {
+		return errors.New("dmarc: missing policy_published domain")
+	}
+	if len(r.Records) == 0 {
+		return errors.New("dmarc: missing record")
+	}
+
+	for i, rec := range r.Records {
+		if rec.Row.SourceIP == "" {
+			return fmt.Errorf("dmarc: record[%d]: missing source_ip", i)
+		}
+		if rec.Row.Count < 0 {
+			return fmt.Errorf("dmarc: record[%d]: negative count", i)
+		}
+	}
+
+	return nil
+}
+
+// Reports splits the aggregate report into one DMARCReport per record.
+func (r DMARCAggregateReport) Reports() []DMARCReport {
+	reports := make([]DMARCReport, 0, len(r.Records))
+	for _, rec := range r.Records {
+		reports = append(reports, DMARCReport{
+			ReportMetadata:  r.ReportMetadata,
+			PolicyPublished: r.PolicyPublished,
+			Record:          rec,
+		})
+	}
+	return reports
+}
@@ -0,0 +1,100 @@
+package types
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dmarcAggregateXML = `<?xml version="1.0" encoding="UTF-8" ?>
+<feedback>
+  <report_metadata>
+    <org_name>google.com</org_name>
+    <email>noreply-dmarc-support@google.com</email>
+    <report_id>1234567890</report_id>
+    <date_range><begin>1700000000</begin><end>1700086399</end></date_range>
+  </report_metadata>
+  <policy_published>
+    <domain>example.com</domain><adkim>r</adkim><aspf>r</aspf><p>none</p><sp>none</sp><pct>100</pct>
+  </policy_published>
+  <record>
+    <row>
+      <source_ip>192.0.2.10</source_ip><count>3</count>
+      <policy_evaluated><disposition>none</disposition><dkim>pass</dkim><spf>fail</spf></policy_evaluated>
+    </row>
+    <identifiers><header_from>example.com</header_from></identifiers>
+    <auth_results>
+      <dkim><domain>example.com</domain><selector>s1</selector><result>pass</result></dkim>
+      <spf><domain>bounce.example.com</domain><result>fail</result></spf>
+    </auth_results>
+  </record>
+  <record>
+    <row>
+      <source_ip>198.51.100.7</source_ip><count>1</count>
+      <policy_evaluated><disposition>none</disposition><dkim>fail</dkim><spf>fail</spf></policy_evaluated>
+    </row>
+    <identifiers><header_from>example.com</header_from></identifiers>
+    <auth_results><spf><domain>spoof.example</domain><result>softfail</result></spf></auth_results>
+  </record>
+</feedback>`
+
+func TestDMARCReport_Type(t *testing.T) {
+	report := DMARCReport{}
+	assert.Equal(t, "dmarc", report.Type())
+}
+
+func TestDMARCAggregateReport_DecodeAndSplit(t *testing.T) {
+	var aggregate DMARCAggregateReport
+	require.NoError(t, xml.Unmarshal([]byte(dmarcAggregateXML), &aggregate))
+	require.NoError(t, aggregate.Validate())
+
+	reports := aggregate.Reports()
+	require.Len(t, reports, 2)
+
+	first := reports[0]
+	assert.Equal(t, "google.com", first.ReportMetadata.OrgName)
+	assert.Equal(t, int64(1700000000), first.ReportMetadata.DateRange.Begin)
+	assert.Equal(t, "example.com", first.PolicyPublished.Domain)
+	assert.Equal(t, 100, first.PolicyPublished.Pct)
+	assert.Equal(t, "192.0.2.10", first.Record.Row.SourceIP)
+	assert.Equal(t, int64(3), first.Record.Row.Count)
+	assert.Equal(t, "s1", first.Record.AuthResults.DKIM[0].Selector)
+
+	// The canonical JSON representation round-trips
+	b, err := first.JSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"source_ip":"192.0.2.10"`)
+	var unmarshaled DMARCReport
+	require.NoError(t, json.Unmarshal(b, &unmarshaled))
+	assert.Equal(t, first, unmarshaled)
+
+	firstHash, err := first.HashData()
+	require.NoError(t, err)
+	secondHash, err := reports[1].HashData()
+	require.NoError(t, err)
+	assert.NotEqual(t, firstHash, secondHash)
+}
+
+func TestDMARCAggregateReport_Validate(t *testing.T) {
+	var aggregate DMARCAggregateReport
+	require.NoError(t, xml.Unmarshal([]byte(dmarcAggregateXML), &aggregate))
+
+	tests := map[string]func(r *DMARCAggregateReport){
+		"missing org name":  func(r *DMARCAggregateReport) { r.ReportMetadata.OrgName = "" },
+		"missing report id": func(r *DMARCAggregateReport) { r.ReportMetadata.ReportID = "" },
+		"missing domain":    func(r *DMARCAggregateReport) { r.PolicyPublished.Domain = "" },
+		"no records":        func(r *DMARCAggregateReport) { r.Records = nil },
+		"missing source ip": func(r *DMARCAggregateReport) { r.Records[1].Row.SourceIP = "" },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := aggregate
+			r.Records = append([]DMARCRecord(nil), aggregate.Records...)
+			mutate(&r)
+			assert.Error(t, r.Validate())
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package types
+
+import "encoding/json"
+
+// DocumentPolicyReport is a wrapper for a Document-Policy violation report body.
+type DocumentPolicyReport struct {
+	URL        string                   `json:"url,omitempty"`
+	ReportType string                   `json:"type,omitempty"`
+	Body       DocumentPolicyReportBody `json:"body"`
+}
+
+// Type returns the type of the report.
+func (r DocumentPolicyReport) Type() string {
+	return "document-policy"
+}
+
+// JSON returns the JSON representation of the report.
+func (r DocumentPolicyReport) JSON() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// HashData returns the data used to generate the report's hash.
+func (r DocumentPolicyReport) HashData() (interface{}, error) {
+	return DocumentPolicyReportHashData{
+		URL:          r.URL,
+		FeatureID:    r.Body.FeatureID,
+		Disposition:  r.Body.Disposition,
+		SourceFile:   r.Body.SourceFile,
+		LineNumber:   r.Body.LineNumber,
+		ColumnNumber: r.Body.ColumnNumber,
+	}, nil
+}
+
+// Summary returns the values of the report used for statistics.
+func (r DocumentPolicyReport) Summary() Summary {
+	return Summary{Directive: r.Body.FeatureID, DocumentURL: r.URL}
+}
+
+// DocumentPolicyReportHashData defines the structure of the data used to generate the report hash.
+type DocumentPolicyReportHashData struct {
+	URL          string `json:"url,omitempty"`
+	FeatureID    string `json:"featureId,omitempty"`
+	Disposition  string `json:"disposition,omitempty"`
+	SourceFile   string `json:"sourceFile,omitempty"`
+	LineNumber   int    `json:"lineNumber,omitempty"`
+	ColumnNumber int    `json:"columnNumber,omitempty"`
+}
+
+// DocumentPolicyReportBody defines the structure of a Document-Policy violation report body.
+type DocumentPolicyReportBody struct {
+	FeatureID    string `json:"featureId,omitempty"`
+	SourceFile   string `json:"sourceFile,omitempty"`
+	LineNumber   int    `json:"lineNumber,omitempty"`
+	ColumnNumber int    `json:"columnNumber,omitempty"`
+	Disposition  string `json:"disposition,omitempty"`
+	Message      string `json:"message,omitempty"`
+}
@@ -0,0 +1,41 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentPolicyReport_Type(t *testing.T) {
+	report := DocumentPolicyReport{}
+	assert.Equal(t, "document-policy", report.Type())
+}
+
+func TestDocumentPolicyReport_JSONAndHashData(t *testing.T) {
+	report := DocumentPolicyReport{
+		URL:        "https://example.com/",
+		ReportType: "document-policy-violation",
+		Body: DocumentPolicyReportBody{
+			FeatureID:   "oversized-images",
+			SourceFile:  "https://example.com/big.png",
+			Disposition: "report",
+			Message:     "Image exceeds the allowed size",
+		},
+	}
+
+	b, err := report.JSON()
+	assert.NoError(t, err)
+	var unmarshaled DocumentPolicyReport
+	assert.NoError(t, json.Unmarshal(b, &unmarshaled))
+	assert.Equal(t, report, unmarshaled)
+
+	hashData, err := report.HashData()
+	assert.NoError(t, err)
+	assert.Equal(t, DocumentPolicyReportHashData{
+		URL:         "https://example.com/",
+		FeatureID:   "oversized-images",
+		Disposition: "report",
+		SourceFile:  "https://example.com/big.png",
+	}, hashData)
+}
@@ -0,0 +1,56 @@
+package types
+
+import "encoding/json"
+
+// ExpectCTReport is a wrapper for a Certificate Transparency (Expect-CT) report body.
+type ExpectCTReport struct {
+	Body ExpectCTReportBody `json:"expect-ct-report"`
+}
+
+// Type returns the type of the report.
+func (r ExpectCTReport) Type() string {
+	return "expect-ct"
+}
+
+// JSON returns the JSON representation of the report.
+func (r ExpectCTReport) JSON() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// HashData returns the data used to generate the report's hash.
+func (r ExpectCTReport) HashData() (interface{}, error) {
+	return ExpectCTReportHashData{
+		Hostname: r.Body.Hostname,
+		Port:     r.Body.Port,
+		SCTs:     r.Body.SCTs,
+	}, nil
+}
+
+// ExpectCTReportHashData defines the structure of the data used to generate the report hash.
+// The certificate chains, the client's date-time and the effective expiration date,
+// which is when the client saw the Expect-CT header plus its max-age, are left out
+// so that the same misissued certificate reported by many clients is stored only once.
+type ExpectCTReportHashData struct {
+	Hostname string        `json:"hostname,omitempty"`
+	Port     int           `json:"port,omitempty"`
+	SCTs     []ExpectCTSCT `json:"scts,omitempty"`
+}
+
+// ExpectCTReportBody defines the structure of an Expect-CT report body.
+type ExpectCTReportBody struct {
+	DateTime                  string        `json:"date-time,omitempty"`
+	Hostname                  string        `json:"hostname,omitempty"`
+	Port                      int           `json:"port,omitempty"`
+	EffectiveExpirationDate   string        `json:"effective-expiration-date,omitempty"`
+	ServedCertificateChain    []string      `json:"served-certificate-chain,omitempty"`
+	ValidatedCertificateChain []string      `json:"validated-certificate-chain,omitempty"`
+	SCTs                      []ExpectCTSCT `json:"scts,omitempty"`
+}
+
+// ExpectCTSCT defines a Signed Certificate Timestamp entry of an Expect-CT report.
+type ExpectCTSCT struct {
+	Version       int    `json:"version,omitempty"`
+	Status        string `json:"status,omitempty"`
+	Source        string `json:"source,omitempty"`
+	SerializedSCT string `json:"serialized_sct,omitempty"`
+}
@@ -0,0 +1,100 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vinsonio/security-report-collector/internal/util"
+)
+
+func TestExpectCTReport_Type(t *testing.T) {
+	report := ExpectCTReport{}
+	assert.Equal(t, "expect-ct", report.Type())
+}
+
+func TestExpectCTReport_JSON(t *testing.T) {
+	report := ExpectCTReport{
+		Body: ExpectCTReportBody{
+			DateTime:                "2025-01-01T00:00:00Z",
+			Hostname:                "example.com",
+			Port:                    443,
+			ServedCertificateChain:  []string{"-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----"},
+			EffectiveExpirationDate: "2025-02-01T00:00:00Z",
+		},
+	}
+
+	b, err := report.JSON()
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"expect-ct-report"`)
+	assert.Contains(t, string(b), `"served-certificate-chain"`)
+
+	var unmarshaled ExpectCTReport
+	assert.NoError(t, json.Unmarshal(b, &unmarshaled))
+	assert.Equal(t, report, unmarshaled)
+}
+
+func TestExpectCTReport_HashData(t *testing.T) {
+	scts := []ExpectCTSCT{{Version: 1, Status: "invalid", Source: "embedded", SerializedSCT: "AAEC"}}
+	report := ExpectCTReport{
+		Body: ExpectCTReportBody{
+			DateTime:                  "2025-01-01T00:00:00Z",
+			Hostname:                  "example.com",
+			Port:                      443,
+			EffectiveExpirationDate:   "2025-02-01T00:00:00Z",
+			ServedCertificateChain:    []string{"served"},
+			ValidatedCertificateChain: []string{"validated"},
+			SCTs:                      scts,
+		},
+	}
+
+	hashData, err := report.HashData()
+	assert.NoError(t, err)
+	assert.Equal(t, ExpectCTReportHashData{
+		Hostname: "example.com",
+		Port:     443,
+		SCTs:     scts,
+	}, hashData)
+
+	// Another client reporting the same misissuance at a different time shares the hash data
+	other := report
+	other.Body.DateTime = "2025-01-03T10:00:00Z"
+	other.Body.ServedCertificateChain = []string{"served", "intermediate"}
+	otherHashData, err := other.HashData()
+	assert.NoError(t, err)
+	assert.Equal(t, hashData, otherHashData)
+}
+
+func TestExpectCTReport_SameHashAcrossClients(t *testing.T) {
+	hash := func(report ExpectCTReport) string {
+		hashData, err := report.HashData()
+		require.NoError(t, err)
+		data, err := util.StableMarshal(hashData)
+		require.NoError(t, err)
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:])
+	}
+
+	scts := []ExpectCTSCT{{Version: 1, Status: "invalid", Source: "embedded", SerializedSCT: "AAEC"}}
+	first := ExpectCTReport{Body: ExpectCTReportBody{
+		DateTime:                "2025-01-01T00:00:00Z",
+		Hostname:                "example.com",
+		Port:                    443,
+		EffectiveExpirationDate: "2025-02-01T00:00:00Z",
+		SCTs:                    scts,
+	}}
+
+	// Two clients that saw the header at different times report the same misissuance
+	second := first
+	second.Body.DateTime = "2025-01-05T12:30:00Z"
+	second.Body.EffectiveExpirationDate = "2025-02-05T12:30:00Z"
+	assert.Equal(t, hash(first), hash(second))
+
+	// A different host is a different misissuance
+	third := first
+	third.Body.Hostname = "other.example.com"
+	assert.NotEqual(t, hash(first), hash(third))
+}
@@ -0,0 +1,133 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/vinsonio/security-report-collector/internal/util"
+)
+
+// Fingerprint normalizers.
+const (
+	// NormalizeStripQuery removes the query string and fragment from a URL.
+	NormalizeStripQuery = "strip_query"
+	// NormalizeOrigin reduces a URL to its origin, e.g. https://cdn.example.com.
+	NormalizeOrigin = "origin"
+	// NormalizeDropLineNumbers removes a trailing :line or :line:column from a
+	// location such as a stack frame, and drops numeric values altogether.
+	NormalizeDropLineNumbers = "drop_line_numbers"
+)
+
+// normalizers maps the normalizer names to their implementations.
+var normalizers = map[string]func(interface{}) interface{}{
+	NormalizeStripQuery:      normalizeString(stripQuery),
+	NormalizeOrigin:          normalizeString(origin),
+	NormalizeDropLineNumbers: dropLineNumbers,
+}
+
+// lineNumberSuffix matches the :line or :line:column suffix of a location.
+var lineNumberSuffix = regexp.MustCompile(`(:\d+){1,2}$`)
+
+// FingerprintField selects a value of a report for its hash.
+type FingerprintField struct {
+	// Pointer is the JSON pointer (RFC 6901) of the value within the report's JSON representation.
+	Pointer string
+	// Normalize lists the normalizers applied to the value, in order.
+	Normalize []string
+}
+
+// Fingerprint computes the hash data of a report from a configured subset of
+// its fields, replacing the report type's own HashData.
+type Fingerprint struct {
+	Fields []FingerprintField
+}
+
+// NewFingerprint returns a fingerprint over the given fields.
+func NewFingerprint(fields []FingerprintField) (*Fingerprint, error) {
+	if len(fields) == 0 {
+		return nil, errors.New("fingerprint has no fields")
+	}
+	for _, field := range fields {
+		if field.Pointer != "" && field.Pointer[0] != '/' {
+			return nil, fmt.Errorf("invalid JSON pointer %q", field.Pointer)
+		}
+		for _, name := range field.Normalize {
+			if _, ok := normalizers[name]; !ok {
+				return nil, fmt.Errorf("unknown normalizer %q", name)
+			}
+		}
+	}
+	return &Fingerprint{Fields: fields}, nil
+}
+
+// HashData returns the normalized values at the fingerprint's pointers within
+// the JSON representation of report, keyed by pointer. Missing values hash as null.
+func (f *Fingerprint) HashData(report Report) (interface{}, error) {
+	data, err := report.JSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{}, len(f.Fields))
+	for _, field := range f.Fields {
+		value, _ := util.ResolvePointer(doc, field.Pointer)
+		for _, name := range field.Normalize {
+			value = normalizers[name](value)
+		}
+		values[field.Pointer] = value
+	}
+	return values, nil
+}
+
+// normalizeString applies fn to string values and leaves other values unchanged.
+func normalizeString(fn func(string) string) func(interface{}) interface{} {
+	return func(v interface{}) interface{} {
+		if s, ok := v.(string); ok {
+			return fn(s)
+		}
+		return v
+	}
+}
+
+// stripQuery removes the query string and fragment from a URL.
+func stripQuery(s string) string {
+	if i := strings.IndexAny(s, "?#"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// origin returns the origin of an absolute URL. Other values, such as the
+// "inline" and "eval" keywords of CSP, are returned unchanged.
+func origin(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return s
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// dropLineNumbers removes the line and column suffix of a location, and drops
+// numeric values such as the lineNumber of a CSP report.
+func dropLineNumbers(v interface{}) interface{} {
+	switch val := v.(type) {
+	case float64:
+		return nil
+	case string:
+		// Keep the port of a bare origin such as https://example.com:8443
+		if u, err := url.Parse(val); err == nil && u.Host != "" && u.Path == "" && u.RawQuery == "" && u.Fragment == "" {
+			return val
+		}
+		return lineNumberSuffix.ReplaceAllString(val, "")
+	}
+	return v
+}
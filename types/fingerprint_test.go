@@ -0,0 +1,72 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	fp, err := NewFingerprint([]FingerprintField{
+		{Pointer: "/body/documentURL", Normalize: []string{NormalizeStripQuery}},
+		{Pointer: "/body/effectiveDirective"},
+		{Pointer: "/body/blockedURL", Normalize: []string{NormalizeOrigin}},
+		{Pointer: "/body/sourceFile", Normalize: []string{NormalizeStripQuery, NormalizeDropLineNumbers}},
+		{Pointer: "/body/lineNumber", Normalize: []string{NormalizeDropLineNumbers}},
+		{Pointer: "/body/sample"},
+	})
+	require.NoError(t, err)
+
+	report := CSPReport{Body: CSPReportBody{
+		DocumentURL:        "https://example.com/checkout?session=abc#top",
+		EffectiveDirective: "script-src-elem",
+		BlockedURL:         "https://CDN.example.com:8443/widget.js?v=3",
+		SourceFile:         "https://example.com/app.js?v=1",
+		LineNumber:         42,
+	}}
+
+	data, err := fp.HashData(report)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"/body/documentURL":        "https://example.com/checkout",
+		"/body/effectiveDirective": "script-src-elem",
+		"/body/blockedURL":         "https://cdn.example.com:8443",
+		"/body/sourceFile":         "https://example.com/app.js",
+		"/body/lineNumber":         nil,
+		"/body/sample":             nil,
+	}, data)
+}
+
+func TestFingerprintNormalizers(t *testing.T) {
+	tests := []struct {
+		normalizer string
+		in         interface{}
+		out        interface{}
+	}{
+		{NormalizeStripQuery, "https://example.com/a?b=c", "https://example.com/a"},
+		{NormalizeStripQuery, "https://example.com/a#b", "https://example.com/a"},
+		{NormalizeStripQuery, float64(1), float64(1)},
+		{NormalizeOrigin, "https://example.com/a?b=c", "https://example.com"},
+		{NormalizeOrigin, "inline", "inline"},
+		{NormalizeOrigin, "data:image/png;base64,AAAA", "data:image/png;base64,AAAA"},
+		{NormalizeDropLineNumbers, "https://example.com/app.js:10:5", "https://example.com/app.js"},
+		{NormalizeDropLineNumbers, "https://example.com/app.js:10", "https://example.com/app.js"},
+		{NormalizeDropLineNumbers, "https://example.com:8443", "https://example.com:8443"},
+		{NormalizeDropLineNumbers, float64(10), nil},
+		{NormalizeDropLineNumbers, true, true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.out, normalizers[tt.normalizer](tt.in), "%s(%v)", tt.normalizer, tt.in)
+	}
+}
+
+func TestNewFingerprint_Invalid(t *testing.T) {
+	_, err := NewFingerprint(nil)
+	assert.Error(t, err)
+	_, err = NewFingerprint([]FingerprintField{{Pointer: "body"}})
+	assert.Error(t, err)
+	_, err = NewFingerprint([]FingerprintField{{Pointer: "/body", Normalize: []string{"lowercase"}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,55 @@
+package types
+
+import "encoding/json"
+
+// InterventionReport is a wrapper for an intervention report body, sent when the
+// browser blocks or alters a request made by the page.
+type InterventionReport struct {
+	URL        string                 `json:"url,omitempty"`
+	ReportType string                 `json:"type,omitempty"`
+	Body       InterventionReportBody `json:"body"`
+}
+
+// Type returns the type of the report.
+func (r InterventionReport) Type() string {
+	return "intervention"
+}
+
+// JSON returns the JSON representation of the report.
+func (r InterventionReport) JSON() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// HashData returns the data used to generate the report's hash.
+func (r InterventionReport) HashData() (interface{}, error) {
+	return InterventionReportHashData{
+		URL:          r.URL,
+		ID:           r.Body.ID,
+		SourceFile:   r.Body.SourceFile,
+		LineNumber:   r.Body.LineNumber,
+		ColumnNumber: r.Body.ColumnNumber,
+	}, nil
+}
+
+// Summary returns the values of the report used for statistics.
+func (r InterventionReport) Summary() Summary {
+	return Summary{Directive: r.Body.ID, DocumentURL: r.URL}
+}
+
+// InterventionReportHashData defines the structure of the data used to generate the report hash.
+type InterventionReportHashData struct {
+	URL          string `json:"url,omitempty"`
+	ID           string `json:"id,omitempty"`
+	SourceFile   string `json:"sourceFile,omitempty"`
+	LineNumber   int    `json:"lineNumber,omitempty"`
+	ColumnNumber int    `json:"columnNumber,omitempty"`
+}
+
+// InterventionReportBody defines the structure of an intervention report body.
+type InterventionReportBody struct {
+	ID           string `json:"id,omitempty"`
+	Message      string `json:"message,omitempty"`
+	SourceFile   string `json:"sourceFile,omitempty"`
+	LineNumber   int    `json:"lineNumber,omitempty"`
+	ColumnNumber int    `json:"columnNumber,omitempty"`
+}
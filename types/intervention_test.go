@@ -0,0 +1,46 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterventionReport_Type(t *testing.T) {
+	report := InterventionReport{}
+	assert.Equal(t, "intervention", report.Type())
+}
+
+func TestInterventionReport_JSONAndHashData(t *testing.T) {
+	report := InterventionReport{
+		URL:        "https://example.com/",
+		ReportType: "intervention",
+		Body: InterventionReportBody{
+			ID:         "audio-no-gesture",
+			Message:    "Autoplay was blocked",
+			SourceFile: "https://example.com/player.js",
+		},
+	}
+	b, err := report.JSON()
+	assert.NoError(t, err)
+	var unmarshaled InterventionReport
+	assert.NoError(t, json.Unmarshal(b, &unmarshaled))
+	assert.Equal(t, report, unmarshaled)
+
+	hashData, err := report.HashData()
+	assert.NoError(t, err)
+	assert.Equal(t, InterventionReportHashData{
+		URL:        "https://example.com/",
+		ID:         "audio-no-gesture",
+		SourceFile: "https://example.com/player.js",
+	}, hashData)
+}
+
+func TestInterventionReport_Summary(t *testing.T) {
+	report := InterventionReport{
+		URL:  "https://example.com/page",
+		Body: InterventionReportBody{ID: "HeavyAdIntervention"},
+	}
+	assert.Equal(t, Summary{Directive: "HeavyAdIntervention", DocumentURL: "https://example.com/page"}, report.Summary())
+}
@@ -0,0 +1,66 @@
+package types
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+// NELReport is a wrapper for a Network Error Logging (network-error) report body.
+type NELReport struct {
+	URL        string        `json:"url,omitempty"`
+	ReportType string        `json:"type,omitempty"`
+	Body       NELReportBody `json:"body"`
+}
+
+// Type returns the type of the report.
+func (r NELReport) Type() string {
+	return "nel"
+}
+
+// JSON returns the JSON representation of the report.
+func (r NELReport) JSON() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// HashData returns the data used to generate the report's hash.
+func (r NELReport) HashData() (interface{}, error) {
+	host := r.URL
+	if u, err := url.Parse(r.URL); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+
+	return NELReportHashData{
+		Host:  host,
+		Phase: r.Body.Phase,
+		Type:  r.Body.Type,
+	}, nil
+}
+
+// Summary returns the values of the report used for statistics.
+func (r NELReport) Summary() Summary {
+	return Summary{DocumentURL: r.URL}
+}
+
+// NELReportHashData defines the structure of the data used to generate the report hash.
+// Network errors are grouped by the host of the failed request and the kind of failure,
+// so that an outage affecting many URLs of the same host is tracked as one issue.
+type NELReportHashData struct {
+	Host  string `json:"host,omitempty"`
+	Phase string `json:"phase,omitempty"`
+	Type  string `json:"type,omitempty"`
+}
+
+// NELReportBody defines the structure of a NEL report body.
+type NELReportBody struct {
+	Referrer         string              `json:"referrer,omitempty"`
+	SamplingFraction float64             `json:"sampling_fraction,omitempty"`
+	ServerIP         string              `json:"server_ip,omitempty"`
+	Protocol         string              `json:"protocol,omitempty"`
+	Method           string              `json:"method,omitempty"`
+	RequestHeaders   map[string][]string `json:"request_headers,omitempty"`
+	ResponseHeaders  map[string][]string `json:"response_headers,omitempty"`
+	StatusCode       int                 `json:"status_code,omitempty"`
+	ElapsedTime      int                 `json:"elapsed_time,omitempty"`
+	Phase            string              `json:"phase,omitempty"`
+	Type             string              `json:"type,omitempty"`
+}
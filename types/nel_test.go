@@ -0,0 +1,59 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNELReport_Type(t *testing.T) {
+	report := NELReport{}
+	assert.Equal(t, "nel", report.Type())
+}
+
+func TestNELReport_JSON(t *testing.T) {
+	report := NELReport{
+		URL:        "https://www.example.com/resource",
+		ReportType: "network-error",
+		Body: NELReportBody{
+			SamplingFraction: 0.5,
+			ServerIP:         "2001:db8::1",
+			Protocol:         "h2",
+			Method:           "GET",
+			ElapsedTime:      823,
+			Phase:            "connection",
+			Type:             "tcp.timed_out",
+		},
+	}
+
+	b, err := report.JSON()
+	assert.NoError(t, err)
+
+	var unmarshaled NELReport
+	assert.NoError(t, json.Unmarshal(b, &unmarshaled))
+	assert.Equal(t, report, unmarshaled)
+}
+
+func TestNELReport_HashData(t *testing.T) {
+	report := NELReport{
+		URL: "https://www.example.com:8443/path?q=1",
+		Body: NELReportBody{
+			ServerIP:    "192.0.2.1",
+			ElapsedTime: 100,
+			Phase:       "dns",
+			Type:        "dns.name_not_resolved",
+		},
+	}
+
+	hashData, err := report.HashData()
+	assert.NoError(t, err)
+	assert.Equal(t, NELReportHashData{Host: "www.example.com", Phase: "dns", Type: "dns.name_not_resolved"}, hashData)
+
+	// Different paths on the same host share a hash
+	other := report
+	other.URL = "https://www.example.com/other"
+	otherHashData, err := other.HashData()
+	assert.NoError(t, err)
+	assert.Equal(t, hashData, otherHashData)
+}
@@ -0,0 +1,57 @@
+package types
+
+import "encoding/json"
+
+// PermissionsPolicyReport is a wrapper for a Permissions-Policy violation report body.
+type PermissionsPolicyReport struct {
+	URL        string                      `json:"url,omitempty"`
+	ReportType string                      `json:"type,omitempty"`
+	Body       PermissionsPolicyReportBody `json:"body"`
+}
+
+// Type returns the type of the report.
+func (r PermissionsPolicyReport) Type() string {
+	return "permissions-policy"
+}
+
+// JSON returns the JSON representation of the report.
+func (r PermissionsPolicyReport) JSON() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// HashData returns the data used to generate the report's hash.
+func (r PermissionsPolicyReport) HashData() (interface{}, error) {
+	return PermissionsPolicyReportHashData{
+		URL:          r.URL,
+		FeatureID:    r.Body.FeatureID,
+		Disposition:  r.Body.Disposition,
+		SourceFile:   r.Body.SourceFile,
+		LineNumber:   r.Body.LineNumber,
+		ColumnNumber: r.Body.ColumnNumber,
+	}, nil
+}
+
+// Summary returns the values of the report used for statistics.
+func (r PermissionsPolicyReport) Summary() Summary {
+	return Summary{Directive: r.Body.FeatureID, DocumentURL: r.URL}
+}
+
+// PermissionsPolicyReportHashData defines the structure of the data used to generate the report hash.
+type PermissionsPolicyReportHashData struct {
+	URL          string `json:"url,omitempty"`
+	FeatureID    string `json:"featureId,omitempty"`
+	Disposition  string `json:"disposition,omitempty"`
+	SourceFile   string `json:"sourceFile,omitempty"`
+	LineNumber   int    `json:"lineNumber,omitempty"`
+	ColumnNumber int    `json:"columnNumber,omitempty"`
+}
+
+// PermissionsPolicyReportBody defines the structure of a Permissions-Policy violation report body.
+type PermissionsPolicyReportBody struct {
+	FeatureID    string `json:"featureId,omitempty"`
+	SourceFile   string `json:"sourceFile,omitempty"`
+	LineNumber   int    `json:"lineNumber,omitempty"`
+	ColumnNumber int    `json:"columnNumber,omitempty"`
+	Disposition  string `json:"disposition,omitempty"`
+	Message      string `json:"message,omitempty"`
+}
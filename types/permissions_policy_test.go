@@ -0,0 +1,53 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermissionsPolicyReport_Type(t *testing.T) {
+	report := PermissionsPolicyReport{}
+	assert.Equal(t, "permissions-policy", report.Type())
+}
+
+func TestPermissionsPolicyReport_JSONAndHashData(t *testing.T) {
+	report := PermissionsPolicyReport{
+		URL:        "https://example.com/",
+		ReportType: "permissions-policy-violation",
+		Body: PermissionsPolicyReportBody{
+			FeatureID:    "camera",
+			SourceFile:   "https://example.com/app.js",
+			LineNumber:   7,
+			ColumnNumber: 3,
+			Disposition:  "enforce",
+			Message:      "Permissions policy violation: camera is not allowed",
+		},
+	}
+
+	b, err := report.JSON()
+	assert.NoError(t, err)
+	var unmarshaled PermissionsPolicyReport
+	assert.NoError(t, json.Unmarshal(b, &unmarshaled))
+	assert.Equal(t, report, unmarshaled)
+
+	hashData, err := report.HashData()
+	assert.NoError(t, err)
+	assert.Equal(t, PermissionsPolicyReportHashData{
+		URL:          "https://example.com/",
+		FeatureID:    "camera",
+		Disposition:  "enforce",
+		SourceFile:   "https://example.com/app.js",
+		LineNumber:   7,
+		ColumnNumber: 3,
+	}, hashData)
+}
+
+func TestPermissionsPolicyReport_Summary(t *testing.T) {
+	report := PermissionsPolicyReport{
+		URL:  "https://example.com/maps",
+		Body: PermissionsPolicyReportBody{FeatureID: "geolocation"},
+	}
+	assert.Equal(t, Summary{Directive: "geolocation", DocumentURL: "https://example.com/maps"}, report.Summary())
+}
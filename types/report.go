@@ -0,0 +1,27 @@
+package types
+
+// Report is an interface that all report types must implement.
+type Report interface {
+	// Type returns the type of the report (e.g., "csp", "hsts").
+	Type() string
+	// JSON returns the canonical JSON representation of the report, which is
+	// what gets stored regardless of the format the report was received in.
+	JSON() ([]byte, error)
+	// HashData returns the data used to generate the report's hash.
+	HashData() (interface{}, error)
+}
+
+// Summary holds the values of a report that statistics are grouped by.
+type Summary struct {
+	// Directive is the violated policy directive or feature, e.g. "script-src".
+	Directive string
+	// BlockedURL is the URL of the blocked resource.
+	BlockedURL string
+	// DocumentURL is the URL of the document the report is about.
+	DocumentURL string
+}
+
+// Summarizer is implemented by reports that provide a Summary for statistics.
+type Summarizer interface {
+	Summary() Summary
+}
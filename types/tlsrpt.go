@@ -0,0 +1,171 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TLSRPTReport is a single policy result of an SMTP TLS Reporting (RFC 8460)
+// aggregate report, together with the metadata of the report it came from.
+// Aggregate reports are split into one TLSRPTReport per policy so that results
+// can be queried per policy domain.
+type TLSRPTReport struct {
+	OrganizationName string             `json:"organization-name"`
+	DateRange        TLSRPTDateRange    `json:"date-range"`
+	ContactInfo      string             `json:"contact-info,omitempty"`
+	ReportID         string             `json:"report-id"`
+	Policy           TLSRPTPolicyResult `json:"policy"`
+}
+
+// Type returns the type of the report.
+func (r TLSRPTReport) Type() string {
+	return "tlsrpt"
+}
+
+// JSON returns the JSON representation of the report.
+func (r TLSRPTReport) JSON() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// HashData returns the data used to generate the report's hash.
+func (r TLSRPTReport) HashData() (interface{}, error) {
+	return TLSRPTReportHashData{
+		OrganizationName: r.OrganizationName,
+		ReportID:         r.ReportID,
+		PolicyType:       r.Policy.Policy.PolicyType,
+		PolicyDomain:     r.Policy.Policy.PolicyDomain,
+		MXHost:           r.Policy.Policy.MXHost,
+	}, nil
+}
+
+// TLSRPTReportHashData defines the structure of the data used to generate the report hash.
+// A policy result is identified by the report it belongs to and the policy it describes,
+// so re-delivered reports are deduplicated while each policy keeps its own row.
+type TLSRPTReportHashData struct {
+	OrganizationName string   `json:"organizationName,omitempty"`
+	ReportID         string   `json:"reportId,omitempty"`
+	PolicyType       string   `json:"policyType,omitempty"`
+	PolicyDomain     string   `json:"policyDomain,omitempty"`
+	MXHost           []string `json:"mxHost,omitempty"`
+}
+
+// TLSRPTAggregateReport defines the structure of an RFC 8460 aggregate report as sent by MTAs.
+type TLSRPTAggregateReport struct {
+	OrganizationName string               `json:"organization-name"`
+	DateRange        TLSRPTDateRange      `json:"date-range"`
+	ContactInfo      string               `json:"contact-info"`
+	ReportID         string               `json:"report-id"`
+	Policies         []TLSRPTPolicyResult `json:"policies"`
+}
+
+// TLSRPTDateRange defines the reporting period of a TLS-RPT report.
+type TLSRPTDateRange struct {
+	StartDatetime string `json:"start-datetime"`
+	EndDatetime   string `json:"end-datetime"`
+}
+
+// TLSRPTPolicyResult defines the result of applying a single policy.
+type TLSRPTPolicyResult struct {
+	Policy         TLSRPTPolicy          `json:"policy"`
+	Summary        TLSRPTSummary         `json:"summary"`
+	FailureDetails []TLSRPTFailureDetail `json:"failure-details,omitempty"`
+}
+
+// TLSRPTPolicy defines the policy a result was evaluated against.
+type TLSRPTPolicy struct {
+	PolicyType   string   `json:"policy-type"`
+	PolicyString []string `json:"policy-string,omitempty"`
+	PolicyDomain string   `json:"policy-domain"`
+	MXHost       []string `json:"mx-host,omitempty"`
+}
+
+// TLSRPTSummary defines the session counts of a policy result.
+type TLSRPTSummary struct {
+	TotalSuccessfulSessionCount int64 `json:"total-successful-session-count"`
+	TotalFailureSessionCount    int64 `json:"total-failure-session-count"`
+}
+
+// TLSRPTFailureDetail defines a single class of failed sessions.
+type TLSRPTFailureDetail struct {
+	ResultType            string `json:"result-type"`
+	SendingMTAIP          string `json:"sending-mta-ip,omitempty"`
+	ReceivingMXHostname   string `json:"receiving-mx-hostname,omitempty"`
+	ReceivingMXHelo       string `json:"receiving-mx-helo,omitempty"`
+	ReceivingIP           string `json:"receiving-ip,omitempty"`
+	FailedSessionCount    int64  `json:"failed-session-count"`
+	AdditionalInformation string `json:"additional-information,omitempty"`
+	FailureReasonCode     string `json:"failure-reason-code,omitempty"`
+}
+
+// tlsrptPolicyTypes lists the policy types defined by RFC 8460.
+var tlsrptPolicyTypes = map[string]bool{
+	"tlsa":            true,
+	"sts":             true,
+	"no-policy-found": true,
+}
+
+// Validate checks the report against the required fields of the RFC 8460 schema.
+func (r TLSRPTAggregateReport) Validate() error {
+	if r.OrganizationName == "" {
+		return errors.New("tlsrpt: missing organization-name")
+	}
+	if r.ContactInfo == "" {
+		return errors.New("tlsrpt: missing contact-info")
+	}
+	if r.ReportID == "" {
+		return errors.New("tlsrpt: missing report-id")
+	}
+	start, err := time.Parse(time.RFC3339, r.DateRange.StartDatetime)
+	if err != nil {
+		return fmt.Errorf("tlsrpt: invalid date-range start-datetime: %w", err)
+	}
+	end, err := time.Parse(time.RFC3339, r.DateRange.EndDatetime)
+	if err != nil {
+		return fmt.Errorf("tlsrpt: invalid date-range end-datetime: %w", err)
+	}
+	if end.Before(start) {
+		return errors.New("tlsrpt: date-range ends before it starts")
+	}
+	if len(r.Policies) == 0 {
+		return errors.New("tlsrpt: missing policies")
+	}
+
+	for i, p := range r.Policies {
+		if !tlsrptPolicyTypes[p.Policy.PolicyType] {
+			return fmt.Errorf("tlsrpt: policies[%d]: invalid policy-type %q", i, p.Policy.PolicyType)
+		}
+		if p.Policy.PolicyDomain == "" {
+			return fmt.Errorf("tlsrpt: policies[%d]: missing policy-domain", i)
+		}
+		if p.Summary.TotalSuccessfulSessionCount < 0 || p.Summary.TotalFailureSessionCount < 0 {
+			return fmt.Errorf("tlsrpt: policies[%d]: negative session count", i)
+		}
+		for j, d := range p.FailureDetails {
+			if d.ResultType == "" {
+				return fmt.Errorf("tlsrpt: policies[%d].failure-details[%d]: missing result-type", i, j)
+			}
+			if d.FailedSessionCount < 0 {
+				return fmt.Errorf("tlsrpt: policies[%d].failure-details[%d]: negative failed-session-count", i, j)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Reports splits the aggregate report into one TLSRPTReport per policy result.
+func (r TLSRPTAggregateReport) Reports() []TLSRPTReport {
+	reports := make([]TLSRPTReport, 0, len(r.Policies))
+	for _, p := range r.Policies {
+		reports = append(reports, TLSRPTReport{
+			OrganizationName: r.OrganizationName,
+			DateRange:        r.DateRange,
+			ContactInfo:      r.ContactInfo,
+			ReportID:         r.ReportID,
+			Policy:           p,
+		})
+	}
+	return reports
+}
@@ -0,0 +1,88 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validTLSRPTAggregateReport() TLSRPTAggregateReport {
+	return TLSRPTAggregateReport{
+		OrganizationName: "Company-X",
+		DateRange: TLSRPTDateRange{
+			StartDatetime: "2016-04-01T00:00:00Z",
+			EndDatetime:   "2016-04-01T23:59:59Z",
+		},
+		ContactInfo: "sts-reporting@company-x.example",
+		ReportID:    "5065427c-23d3-47ca-b6e0-946ea0e8c4be",
+		Policies: []TLSRPTPolicyResult{
+			{
+				Policy: TLSRPTPolicy{
+					PolicyType:   "sts",
+					PolicyString: []string{"version: STSv1", "mode: testing"},
+					PolicyDomain: "company-y.example",
+					MXHost:       []string{"*.mail.company-y.example"},
+				},
+				Summary: TLSRPTSummary{TotalSuccessfulSessionCount: 5326, TotalFailureSessionCount: 303},
+				FailureDetails: []TLSRPTFailureDetail{
+					{ResultType: "certificate-expired", SendingMTAIP: "2001:db8:abcd:0012::1", ReceivingMXHostname: "mx1.mail.company-y.example", FailedSessionCount: 100},
+				},
+			},
+			{
+				Policy:  TLSRPTPolicy{PolicyType: "no-policy-found", PolicyDomain: "company-z.example"},
+				Summary: TLSRPTSummary{TotalSuccessfulSessionCount: 10},
+			},
+		},
+	}
+}
+
+func TestTLSRPTReport_Type(t *testing.T) {
+	report := TLSRPTReport{}
+	assert.Equal(t, "tlsrpt", report.Type())
+}
+
+func TestTLSRPTAggregateReport_Validate(t *testing.T) {
+	assert.NoError(t, validTLSRPTAggregateReport().Validate())
+
+	tests := map[string]func(r *TLSRPTAggregateReport){
+		"missing organization": func(r *TLSRPTAggregateReport) { r.OrganizationName = "" },
+		"missing contact":      func(r *TLSRPTAggregateReport) { r.ContactInfo = "" },
+		"missing report id":    func(r *TLSRPTAggregateReport) { r.ReportID = "" },
+		"invalid start":        func(r *TLSRPTAggregateReport) { r.DateRange.StartDatetime = "yesterday" },
+		"reversed range":       func(r *TLSRPTAggregateReport) { r.DateRange.EndDatetime = "2016-03-01T00:00:00Z" },
+		"no policies":          func(r *TLSRPTAggregateReport) { r.Policies = nil },
+		"invalid policy type":  func(r *TLSRPTAggregateReport) { r.Policies[0].Policy.PolicyType = "dane" },
+		"missing domain":       func(r *TLSRPTAggregateReport) { r.Policies[1].Policy.PolicyDomain = "" },
+		"negative count":       func(r *TLSRPTAggregateReport) { r.Policies[0].Summary.TotalFailureSessionCount = -1 },
+		"missing result type":  func(r *TLSRPTAggregateReport) { r.Policies[0].FailureDetails[0].ResultType = "" },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := validTLSRPTAggregateReport()
+			mutate(&r)
+			assert.Error(t, r.Validate())
+		})
+	}
+}
+
+func TestTLSRPTAggregateReport_Reports(t *testing.T) {
+	aggregate := validTLSRPTAggregateReport()
+
+	reports := aggregate.Reports()
+	assert.Len(t, reports, 2)
+	assert.Equal(t, "company-y.example", reports[0].Policy.Policy.PolicyDomain)
+	assert.Equal(t, "company-z.example", reports[1].Policy.Policy.PolicyDomain)
+	assert.Equal(t, aggregate.ReportID, reports[1].ReportID)
+
+	first, err := reports[0].HashData()
+	assert.NoError(t, err)
+	second, err := reports[1].HashData()
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, TLSRPTReportHashData{
+		OrganizationName: "Company-X",
+		ReportID:         "5065427c-23d3-47ca-b6e0-946ea0e8c4be",
+		PolicyType:       "no-policy-found",
+		PolicyDomain:     "company-z.example",
+	}, second)
+}
@@ -0,0 +1,129 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// CSPReport is a wrapper for the CSP report body
+type CSPReport struct {
+	URL        string        `json:"url,omitempty"`
+	ReportType string        `json:"type,omitempty"`
+	Body       CSPReportBody `json:"body"`
+}
+
+// Type returns the type of the report.
+func (r CSPReport) Type() string {
+	return "csp"
+}
+
+// JSON returns the JSON representation of the report.
+func (r CSPReport) JSON() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// HashData returns the data used to generate the report's hash.
+func (r CSPReport) HashData() (interface{}, error) {
+	return CSPReportHashData{
+		DocumentURL:        r.Body.DocumentURL,
+		EffectiveDirective: r.Body.EffectiveDirective,
+		BlockedURL:         r.Body.BlockedURL,
+		SourceFile:         r.Body.SourceFile,
+		LineNumber:         r.Body.LineNumber,
+		ColumnNumber:       r.Body.ColumnNumber,
+	}, nil
+}
+
+// Summary returns the values of the report used for statistics.
+func (r CSPReport) Summary() Summary {
+	documentURL := r.Body.DocumentURL
+	if documentURL == "" {
+		documentURL = r.URL
+	}
+	return Summary{Directive: r.Body.EffectiveDirective, BlockedURL: r.Body.BlockedURL, DocumentURL: documentURL}
+}
+
+// CSPReportHashData defines the structure of the data used to generate the report hash.
+// This is a subset of the full CSP report, containing only the fields that uniquely
+// identify a specific violation.
+// This helps in deduplicating reports and tracking the frequency of specific issues.
+type CSPReportHashData struct {
+	DocumentURL        string `json:"documentURL,omitempty"`
+	EffectiveDirective string `json:"effectiveDirective,omitempty"`
+	BlockedURL         string `json:"blockedURL,omitempty"`
+	SourceFile         string `json:"sourceFile,omitempty"`
+	LineNumber         int    `json:"lineNumber,omitempty"`
+	ColumnNumber       int    `json:"columnNumber,omitempty"`
+}
+
+// CSPReportBody defines the structure of a CSP report body.
+type CSPReportBody struct {
+	DocumentURL        string `json:"documentURL,omitempty"`
+	Disposition        string `json:"disposition,omitempty"`
+	Referrer           string `json:"referrer,omitempty"`
+	EffectiveDirective string `json:"effectiveDirective,omitempty"`
+	BlockedURL         string `json:"blockedURL,omitempty"`
+	OriginalPolicy     string `json:"originalPolicy,omitempty"`
+	StatusCode         int    `json:"statusCode,omitempty"`
+	Sample             string `json:"sample,omitempty"`
+	SourceFile         string `json:"sourceFile,omitempty"`
+	LineNumber         int    `json:"lineNumber,omitempty"`
+	ColumnNumber       int    `json:"columnNumber,omitempty"`
+}
+
+// LegacyCSPReport is the report-uri envelope sent with the application/csp-report
+// content type, e.g. {"csp-report": {"document-uri": "..."}}.
+type LegacyCSPReport struct {
+	Body *LegacyCSPReportBody `json:"csp-report"`
+}
+
+// LegacyCSPReportBody defines the kebab-case body of a report-uri CSP report.
+type LegacyCSPReportBody struct {
+	DocumentURI        string `json:"document-uri,omitempty"`
+	Referrer           string `json:"referrer,omitempty"`
+	ViolatedDirective  string `json:"violated-directive,omitempty"`
+	EffectiveDirective string `json:"effective-directive,omitempty"`
+	OriginalPolicy     string `json:"original-policy,omitempty"`
+	Disposition        string `json:"disposition,omitempty"`
+	BlockedURI         string `json:"blocked-uri,omitempty"`
+	StatusCode         int    `json:"status-code,omitempty"`
+	ScriptSample       string `json:"script-sample,omitempty"`
+	SourceFile         string `json:"source-file,omitempty"`
+	LineNumber         int    `json:"line-number,omitempty"`
+	ColumnNumber       int    `json:"column-number,omitempty"`
+}
+
+// CSPReport converts the legacy report into the Reporting API representation so
+// that both formats are stored and deduplicated the same way.
+func (r LegacyCSPReport) CSPReport() CSPReport {
+	if r.Body == nil {
+		return CSPReport{ReportType: "csp-violation"}
+	}
+
+	// Older browsers only send violated-directive, which may carry the full
+	// directive value (e.g. "script-src 'self'"); keep just the directive name.
+	directive := r.Body.EffectiveDirective
+	if directive == "" {
+		if fields := strings.Fields(r.Body.ViolatedDirective); len(fields) > 0 {
+			directive = fields[0]
+		}
+	}
+
+	return CSPReport{
+		URL:        r.Body.DocumentURI,
+		ReportType: "csp-violation",
+		Body: CSPReportBody{
+			DocumentURL:        r.Body.DocumentURI,
+			Disposition:        r.Body.Disposition,
+			Referrer:           r.Body.Referrer,
+			EffectiveDirective: directive,
+			BlockedURL:         r.Body.BlockedURI,
+			OriginalPolicy:     r.Body.OriginalPolicy,
+			StatusCode:         r.Body.StatusCode,
+			Sample:             r.Body.ScriptSample,
+			SourceFile:         r.Body.SourceFile,
+			LineNumber:         r.Body.LineNumber,
+			ColumnNumber:       r.Body.ColumnNumber,
+		},
+	}
+}
@@ -85,3 +85,45 @@ func TestCSPReport_EmptyFields(t *testing.T) {
 	expected := CSPReportHashData{}
 	assert.Equal(t, expected, hashData)
 }
+
+func TestLegacyCSPReport_CSPReport(t *testing.T) {
+	legacy := LegacyCSPReport{
+		Body: &LegacyCSPReportBody{
+			DocumentURI:        "https://example.com/page",
+			ViolatedDirective:  "style-src 'self'",
+			EffectiveDirective: "style-src-elem",
+			BlockedURI:         "inline",
+			ScriptSample:       "body{}",
+		},
+	}
+
+	report := legacy.CSPReport()
+	assert.Equal(t, "csp-violation", report.ReportType)
+	assert.Equal(t, "https://example.com/page", report.URL)
+	assert.Equal(t, "style-src-elem", report.Body.EffectiveDirective)
+	assert.Equal(t, "inline", report.Body.BlockedURL)
+	assert.Equal(t, "body{}", report.Body.Sample)
+
+	// Falls back to the directive name from violated-directive
+	legacy.Body.EffectiveDirective = ""
+	assert.Equal(t, "style-src", legacy.CSPReport().Body.EffectiveDirective)
+}
+
+func TestCSPReport_Summary(t *testing.T) {
+	report := CSPReport{
+		URL: "https://example.com/page",
+		Body: CSPReportBody{
+			DocumentURL:        "https://example.com/checkout?step=2",
+			EffectiveDirective: "script-src-elem",
+			BlockedURL:         "https://cdn.example.net/app.js",
+		},
+	}
+	assert.Equal(t, Summary{
+		Directive:   "script-src-elem",
+		BlockedURL:  "https://cdn.example.net/app.js",
+		DocumentURL: "https://example.com/checkout?step=2",
+	}, report.Summary())
+
+	report.Body.DocumentURL = ""
+	assert.Equal(t, "https://example.com/page", report.Summary().DocumentURL)
+}